@@ -0,0 +1,327 @@
+package pdf
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ActionType is the kind of a PDF action.
+type ActionType int
+
+const (
+	// ActionURI opens the URI.
+	ActionURI ActionType = iota + 1
+	// ActionGoTo goes to the Destination or the named destination Dest in
+	// this document.
+	ActionGoTo
+	// ActionGoToR goes to the Destination in another PDF file.
+	ActionGoToR
+	// ActionGoToE goes to the Destination in an embedded PDF file.
+	ActionGoToE
+	// ActionLaunch opens or runs File.
+	ActionLaunch
+	// ActionNamed runs the viewer command Name such as NextPage or Print.
+	ActionNamed
+	// ActionJavaScript runs JavaScript.
+	ActionJavaScript
+	// ActionSubmitForm sends the form fields to URL.
+	ActionSubmitForm
+	// ActionResetForm resets the form fields to their default values.
+	ActionResetForm
+	// ActionHide hides or shows the form fields.
+	ActionHide
+	// ActionSetOCGState switches optional content groups on or off.
+	ActionSetOCGState
+)
+
+var actionTypeNames = map[ActionType]string{
+	ActionURI:         "/URI",
+	ActionGoTo:        "/GoTo",
+	ActionGoToR:       "/GoToR",
+	ActionGoToE:       "/GoToE",
+	ActionLaunch:      "/Launch",
+	ActionNamed:       "/Named",
+	ActionJavaScript:  "/JavaScript",
+	ActionSubmitForm:  "/SubmitForm",
+	ActionResetForm:   "/ResetForm",
+	ActionHide:        "/Hide",
+	ActionSetOCGState: "/SetOCGState",
+}
+
+// Flags for SubmitForm and ResetForm actions.
+const (
+	// FormActionExclude submits or resets all fields except the ones listed
+	// in Fields.
+	FormActionExclude = 1 << 0
+	// SubmitIncludeNoValueFields also submits fields without a value.
+	SubmitIncludeNoValueFields = 1 << 1
+	// SubmitHTML submits the fields as an HTML form instead of FDF.
+	SubmitHTML = 1 << 2
+	// SubmitGetMethod uses HTTP GET instead of POST for HTML forms.
+	SubmitGetMethod = 1 << 3
+	// SubmitXFDF submits the fields as XFDF.
+	SubmitXFDF = 1 << 5
+	// SubmitPDF submits the whole document.
+	SubmitPDF = 1 << 8
+)
+
+// OCGStateChange is one step of a SetOCGState action. State is ON, OFF or
+// Toggle.
+type OCGStateChange struct {
+	State Name
+	OCGs  []Objectnumber
+}
+
+// Action is a PDF action which is performed when the user activates an
+// annotation or an outline entry, when the document is opened or when a page
+// is opened or closed. Only the fields the Type needs are used.
+type Action struct {
+	Type ActionType
+	// URI is the target of a URI action.
+	URI string
+	// Destination is the target of GoTo, GoToR and GoToE actions.
+	Destination *Destination
+	// Dest is a named destination in this document for a GoTo action.
+	Dest string
+	// File is the file or application of a Launch action.
+	File string
+	// NewWindow opens the file of a Launch action in a new window.
+	NewWindow bool
+	// Name is the viewer command of a Named action: NextPage, PrevPage,
+	// FirstPage, LastPage or a viewer specific name.
+	Name Name
+	// JavaScript is the script of a JavaScript action.
+	JavaScript string
+	// URL is the target of a SubmitForm action.
+	URL string
+	// Fields are the fully qualified names of the form fields a
+	// SubmitForm, ResetForm or Hide action works on. SubmitForm and
+	// ResetForm use all fields if empty.
+	Fields []string
+	// Flags are the SubmitForm and ResetForm flags.
+	Flags int
+	// Show makes a Hide action show the fields instead.
+	Show bool
+	// State are the changes of a SetOCGState action.
+	State []OCGStateChange
+	// Next are the actions performed after this action.
+	Next []*Action
+}
+
+// AdditionalActions maps a trigger event to an action, for example O and C
+// for opening and closing a page or E and X for entering and leaving an
+// annotation with the mouse.
+type AdditionalActions map[Name]*Action
+
+var (
+	pageTriggers       = []Name{"O", "C"}
+	annotationTriggers = []Name{"E", "X", "D", "U", "Fo", "Bl", "PO", "PC", "PV", "PI"}
+)
+
+// textStrings returns a PDF array of text strings.
+func textStrings(strs []string) string {
+	s := make([]string, len(strs))
+	for i, str := range strs {
+		s[i] = stringToPDF(str)
+	}
+	return "[" + strings.Join(s, " ") + "]"
+}
+
+// dict returns the action dictionary.
+func (a *Action) dict() Dict {
+	d := Dict{"S": actionTypeNames[a.Type]}
+	switch a.Type {
+	case ActionURI:
+		d["URI"] = stringToPDF(a.URI)
+	case ActionGoTo:
+		if a.Destination != nil {
+			d["D"] = a.Destination.explicit()
+		} else {
+			d["D"] = stringToPDF(a.Dest)
+		}
+	case ActionGoToR, ActionGoToE:
+		dest := a.Destination
+		d["D"] = dest.target()
+		if dest.Embedded != nil {
+			d["T"] = Dict{"R": "/C", "N": stringToPDF(dest.Embedded.Name)}
+		} else {
+			d["F"] = Dict{"Type": "/Filespec", "F": stringToPDF(dest.File), "UF": stringToPDF(dest.File)}
+		}
+		if dest.NewWindow {
+			d["NewWindow"] = "true"
+		}
+	case ActionLaunch:
+		d["F"] = Dict{"Type": "/Filespec", "F": stringToPDF(a.File), "UF": stringToPDF(a.File)}
+		if a.NewWindow {
+			d["NewWindow"] = "true"
+		}
+	case ActionNamed:
+		d["N"] = a.Name.String()
+	case ActionJavaScript:
+		d["JS"] = stringToPDF(a.JavaScript)
+	case ActionSubmitForm:
+		d["F"] = Dict{"FS": "/URL", "F": stringToPDF(a.URL)}
+		fallthrough
+	case ActionResetForm:
+		if len(a.Fields) > 0 {
+			d["Fields"] = textStrings(a.Fields)
+		}
+		if a.Flags != 0 {
+			d["Flags"] = a.Flags
+		}
+	case ActionHide:
+		d["T"] = textStrings(a.Fields)
+		if a.Show {
+			d["H"] = "false"
+		}
+	case ActionSetOCGState:
+		var state []string
+		for _, sc := range a.State {
+			state = append(state, sc.State.String())
+			for _, ocg := range sc.OCGs {
+				state = append(state, ocg.Ref())
+			}
+		}
+		d["State"] = "[" + strings.Join(state, " ") + "]"
+	}
+	switch len(a.Next) {
+	case 0:
+	case 1:
+		d["Next"] = a.Next[0]
+	default:
+		next := make(Array, len(a.Next))
+		for i, n := range a.Next {
+			next[i] = n
+		}
+		d["Next"] = next
+	}
+	return d
+}
+
+// pdfaNamedActions are the only named actions PDF/A allows.
+var pdfaNamedActions = []Name{"NextPage", "PrevPage", "FirstPage", "LastPage"}
+
+// checkAction validates the action and the actions chained with Next.
+func (pw *PDF) checkAction(a *Action) error {
+	return pw.checkActionChain(a, map[*Action]bool{})
+}
+
+func (pw *PDF) checkActionChain(a *Action, seen map[*Action]bool) error {
+	if a == nil {
+		return fmt.Errorf("pdf: nil action")
+	}
+	if seen[a] {
+		return fmt.Errorf("pdf: action chain contains a loop")
+	}
+	seen[a] = true
+	if err := pw.checkActionEntries(a); err != nil {
+		return err
+	}
+	if pw.PDFA != nil {
+		forbidden := []ActionType{ActionLaunch, ActionJavaScript, ActionResetForm, ActionHide, ActionSetOCGState}
+		if pw.PDFA.Level == PDFA1b {
+			// GoToE is PDF 1.6, PDF/A-1 is based on PDF 1.4
+			forbidden = append(forbidden, ActionGoToE)
+		}
+		switch {
+		case slices.Contains(forbidden, a.Type):
+			pw.conformanceErrors = append(pw.conformanceErrors, fmt.Errorf("pdf: PDF/A-%s does not allow %s actions", pw.PDFA.Level.part(), strings.TrimPrefix(actionTypeNames[a.Type], "/")))
+		case a.Type == ActionNamed && !slices.Contains(pdfaNamedActions, Name(strings.TrimPrefix(string(a.Name), "/"))):
+			pw.conformanceErrors = append(pw.conformanceErrors, fmt.Errorf("pdf: PDF/A does not allow the named action %s", a.Name))
+		}
+	}
+	for _, n := range a.Next {
+		if err := pw.checkActionChain(n, seen); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkActionEntries makes sure that the entries the action type needs are
+// present.
+func (pw *PDF) checkActionEntries(a *Action) error {
+	switch a.Type {
+	case ActionURI:
+		if a.URI == "" {
+			return fmt.Errorf("pdf: URI action without a URI")
+		}
+		for _, r := range a.URI {
+			if r > 0x7e || r < 0x20 {
+				return fmt.Errorf("pdf: URI %q must be 7-bit ASCII, use percent encoding", a.URI)
+			}
+		}
+	case ActionGoTo:
+		if (a.Destination == nil) == (a.Dest == "") {
+			return fmt.Errorf("pdf: GoTo action needs either a destination or a named destination")
+		}
+		if a.Destination != nil {
+			if a.Destination.remote() {
+				return fmt.Errorf("pdf: GoTo action to another document, use GoToR or GoToE")
+			}
+			return pw.checkDestination(a.Destination)
+		}
+	case ActionGoToR, ActionGoToE:
+		d := a.Destination
+		if d == nil {
+			return fmt.Errorf("pdf: %s action without a destination", strings.TrimPrefix(actionTypeNames[a.Type], "/"))
+		}
+		if a.Type == ActionGoToR && d.File == "" {
+			return fmt.Errorf("pdf: GoToR action needs a destination with a file")
+		}
+		if a.Type == ActionGoToE && d.Embedded == nil {
+			return fmt.Errorf("pdf: GoToE action needs a destination with an embedded file")
+		}
+		return pw.checkDestination(d)
+	case ActionLaunch:
+		if a.File == "" {
+			return fmt.Errorf("pdf: Launch action without a file")
+		}
+	case ActionNamed:
+		if a.Name == "" {
+			return fmt.Errorf("pdf: Named action without a name")
+		}
+	case ActionJavaScript:
+		if a.JavaScript == "" {
+			return fmt.Errorf("pdf: JavaScript action without a script")
+		}
+	case ActionSubmitForm:
+		if a.URL == "" {
+			return fmt.Errorf("pdf: SubmitForm action without a URL")
+		}
+	case ActionResetForm:
+	case ActionHide:
+		if len(a.Fields) == 0 {
+			return fmt.Errorf("pdf: Hide action without fields")
+		}
+	case ActionSetOCGState:
+		if len(a.State) == 0 {
+			return fmt.Errorf("pdf: SetOCGState action without state changes")
+		}
+		for _, sc := range a.State {
+			if sc.State != "ON" && sc.State != "OFF" && sc.State != "Toggle" {
+				return fmt.Errorf("pdf: unknown optional content state %q, want ON, OFF or Toggle", sc.State)
+			}
+		}
+	default:
+		return fmt.Errorf("pdf: unknown action type %d", a.Type)
+	}
+	return nil
+}
+
+// checkAdditionalActions validates the actions and their trigger events.
+func (pw *PDF) checkAdditionalActions(aa AdditionalActions, triggers []Name) error {
+	if pw.PDFA != nil {
+		pw.conformanceErrors = append(pw.conformanceErrors, fmt.Errorf("pdf: PDF/A does not allow additional actions"))
+	}
+	for trigger, a := range aa {
+		if !slices.Contains(triggers, trigger) {
+			return fmt.Errorf("pdf: unknown trigger event %q for additional actions", trigger)
+		}
+		if err := pw.checkAction(a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package pdf
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestActionSerialize(t *testing.T) {
+	for _, tc := range []struct {
+		a    *Action
+		want []string
+	}{
+		{&Action{Type: ActionURI, URI: "https://example.com/(a)"}, []string{"/S /URI", `/URI (https://example.com/\(a\))`}},
+		{&Action{Type: ActionGoTo, Dest: "chapter1"}, []string{"/S /GoTo", "/D (chapter1)"}},
+		{&Action{Type: ActionGoTo, Destination: &Destination{Page: 4, Fit: Fit}}, []string{"/D [4 0 R /Fit]"}},
+		{&Action{Type: ActionGoToR, Destination: &Destination{File: "other.pdf", PageIndex: 1, Fit: Fit}}, []string{"/S /GoToR", "/D [1 /Fit]", "/F (other.pdf)"}},
+		{&Action{Type: ActionLaunch, File: "readme.txt", NewWindow: true}, []string{"/S /Launch", "/F (readme.txt)", "/NewWindow true"}},
+		{&Action{Type: ActionNamed, Name: "NextPage"}, []string{"/S /Named", "/N /NextPage"}},
+		{&Action{Type: ActionJavaScript, JavaScript: "app.alert('hi');"}, []string{"/S /JavaScript", `/JS (app.alert\('hi'\);)`}},
+		{&Action{Type: ActionSubmitForm, URL: "https://example.com/submit", Fields: []string{"name"}, Flags: SubmitHTML}, []string{"/S /SubmitForm", "/FS /URL", "/Fields [(name)]", "/Flags 4"}},
+		{&Action{Type: ActionResetForm}, []string{"/S /ResetForm"}},
+		{&Action{Type: ActionHide, Fields: []string{"a", "b"}, Show: true}, []string{"/S /Hide", "/T [(a) (b)]", "/H false"}},
+		{&Action{Type: ActionSetOCGState, State: []OCGStateChange{{State: "OFF", OCGs: []Objectnumber{7}}, {State: "ON", OCGs: []Objectnumber{8, 9}}}}, []string{"/S /SetOCGState", "/State [/OFF 7 0 R /ON 8 0 R 9 0 R]"}},
+	} {
+		pw, _ := newTestPDF()
+		if err := pw.checkAction(tc.a); err != nil {
+			t.Errorf("%s: %s", actionTypeNames[tc.a.Type], err)
+			continue
+		}
+		got := Serialize(tc.a)
+		for _, want := range tc.want {
+			if !strings.Contains(got, want) {
+				t.Errorf("Serialize() = %q, does not contain %q", got, want)
+			}
+		}
+	}
+}
+
+func TestActionNext(t *testing.T) {
+	third := &Action{Type: ActionNamed, Name: "LastPage"}
+	a := &Action{Type: ActionURI, URI: "https://example.com", Next: []*Action{{Type: ActionNamed, Name: "NextPage"}}}
+	got := Serialize(a)
+	if !strings.Contains(got, "/Next <<") || !strings.Contains(got, "/N /NextPage") {
+		t.Errorf("single Next action not written as a dictionary: %q", got)
+	}
+	a.Next = append(a.Next, third)
+	got = Serialize(a)
+	if !strings.Contains(got, "/Next [") || !strings.Contains(got, "/N /LastPage") {
+		t.Errorf("several Next actions not written as an array: %q", got)
+	}
+
+	pw, _ := newTestPDF()
+	third.Next = []*Action{a}
+	if err := pw.checkAction(a); err == nil {
+		t.Error("expected an error for an action loop")
+	}
+}
+
+func TestActionErrors(t *testing.T) {
+	pw, _ := newTestPDF()
+	for _, a := range []*Action{
+		nil,
+		{},
+		{Type: ActionURI},
+		{Type: ActionURI, URI: "https://example.com/ä"},
+		{Type: ActionGoTo},
+		{Type: ActionGoTo, Dest: "x", Destination: &Destination{Page: 1}},
+		{Type: ActionGoTo, Destination: &Destination{File: "a.pdf"}},
+		{Type: ActionGoToR, Destination: &Destination{Page: 1}},
+		{Type: ActionGoToE, Destination: &Destination{File: "a.pdf"}},
+		{Type: ActionLaunch},
+		{Type: ActionNamed},
+		{Type: ActionJavaScript},
+		{Type: ActionSubmitForm},
+		{Type: ActionHide},
+		{Type: ActionSetOCGState},
+		{Type: ActionSetOCGState, State: []OCGStateChange{{State: "Off", OCGs: []Objectnumber{1}}}},
+		{Type: ActionURI, URI: "https://example.com", Next: []*Action{{Type: ActionLaunch}}},
+	} {
+		if err := pw.checkAction(a); err == nil {
+			t.Errorf("expected an error for %v", a)
+		}
+	}
+}
+
+func TestOpenActionAndAdditionalActions(t *testing.T) {
+	pw, buf := newTestPDF()
+	page := pw.AddPage(pw.NewObject(), 0)
+	page.AdditionalActions = AdditionalActions{"O": {Type: ActionJavaScript, JavaScript: "opened();"}}
+	page.Annotations = append(page.Annotations, Annotation{
+		Subtype:           "Link",
+		Rect:              [4]float64{0, 0, 10, 10},
+		OnActivate:        &Action{Type: ActionURI, URI: "https://example.com"},
+		AdditionalActions: AdditionalActions{"E": {Type: ActionNamed, Name: "NextPage"}},
+	})
+	pw.OpenAction = &Action{Type: ActionGoTo, Destination: &Destination{Page: page.Objnum, Fit: Fit}}
+	if err := pw.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		fmt.Sprintf("/D [%s /Fit]", page.Objnum.Ref()),
+		`/JS (opened\(\);)`,
+		"/URI (https://example.com)",
+		"/N /NextPage",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q", want)
+		}
+	}
+
+	pw, _ = newTestPDF()
+	page = pw.AddPage(pw.NewObject(), 0)
+	page.AdditionalActions = AdditionalActions{"X": {Type: ActionNamed, Name: "NextPage"}}
+	if err := pw.Finish(); err == nil {
+		t.Error("expected an error for an annotation trigger on a page")
+	}
+}
+
+func TestActionPDFALevels(t *testing.T) {
+	embedded := &Attachment{Name: "a.pdf"}
+	ocg := &Action{Type: ActionSetOCGState, State: []OCGStateChange{{State: "OFF", OCGs: []Objectnumber{7}}}}
+	gotoE := &Action{Type: ActionGoToE, Destination: &Destination{Embedded: embedded}}
+	for _, tc := range []struct {
+		level   PDFALevel
+		action  *Action
+		allowed bool
+	}{
+		{PDFA1b, ocg, false},
+		{PDFA2b, ocg, false},
+		{PDFA3b, ocg, false},
+		{PDFA1b, gotoE, false},
+		{PDFA2b, gotoE, true},
+		{PDFA2b, &Action{Type: ActionNamed, Name: "NextPage"}, true},
+		{PDFA1b, &Action{Type: ActionNamed, Name: "LastPage"}, true},
+		{PDFA2b, &Action{Type: ActionNamed, Name: "GoToPage"}, false},
+		{PDFA2b, &Action{Type: ActionNamed, Name: "Print"}, false},
+	} {
+		pw, _ := newTestPDF()
+		pw.PDFA = &PDFA{Level: tc.level}
+		pw.attachments = []*Attachment{embedded}
+		if err := pw.checkAction(tc.action); err != nil {
+			t.Fatal(err)
+		}
+		if allowed := len(pw.conformanceErrors) == 0; allowed != tc.allowed {
+			t.Errorf("PDF/A-%s, %s %s: allowed = %t, want %t", tc.level.part(), actionTypeNames[tc.action.Type], tc.action.Name, allowed, tc.allowed)
+		}
+	}
+}
+
+func TestAnnotationStringAction(t *testing.T) {
+	pw, buf := newTestPDF()
+	page := pw.AddPage(pw.NewObject(), 0)
+	page.Annotations = append(page.Annotations, Annotation{
+		Subtype: "Link",
+		Rect:    [4]float64{0, 0, 10, 10},
+		Action:  "<< /S /URI /URI (https://example.com) >>",
+	})
+	if err := pw.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "/A << /S /URI /URI (https://example.com) >>") {
+		t.Error("string action not written")
+	}
+
+	pw, _ = newTestPDF()
+	page = pw.AddPage(pw.NewObject(), 0)
+	page.Annotations = append(page.Annotations, Annotation{
+		Subtype:    "Link",
+		Rect:       [4]float64{0, 0, 10, 10},
+		Action:     "<< /S /URI /URI (https://example.com) >>",
+		OnActivate: &Action{Type: ActionURI, URI: "https://example.com"},
+	})
+	if err := pw.Finish(); err == nil {
+		t.Error("expected an error for an annotation with Action and OnActivate")
+	}
+}
@@ -0,0 +1,607 @@
+package pdf
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Annotation flags.
+const (
+	// AnnotationInvisible hides unknown annotation types.
+	AnnotationInvisible = 1 << 0
+	// AnnotationHidden neither shows nor prints the annotation.
+	AnnotationHidden = 1 << 1
+	// AnnotationPrint prints the annotation.
+	AnnotationPrint = 1 << 2
+	// AnnotationNoZoom keeps the size of the annotation when zooming.
+	AnnotationNoZoom = 1 << 3
+	// AnnotationNoRotate keeps the annotation upright when the page is
+	// rotated.
+	AnnotationNoRotate = 1 << 4
+	// AnnotationNoView prints the annotation but does not show it.
+	AnnotationNoView = 1 << 5
+	// AnnotationReadOnly does not let the user interact with the
+	// annotation.
+	AnnotationReadOnly = 1 << 6
+	// AnnotationLocked does not let the user move or delete the annotation.
+	AnnotationLocked = 1 << 7
+)
+
+// markupSubtypes are the annotation types with markup entries and a
+// generated appearance stream.
+var markupSubtypes = []Name{
+	"Text", "FreeText", "Highlight", "Underline", "StrikeOut", "Squiggly",
+	"Square", "Circle", "Line", "Polygon", "PolyLine", "Ink", "Stamp", "Caret",
+}
+
+// NewTextAnnotation returns a note (sticky note) at the rectangle which shows
+// the contents in a pop-up window.
+func NewTextAnnotation(rect [4]float64, contents string) Annotation {
+	return Annotation{
+		Subtype:  "Text",
+		Rect:     rect,
+		Contents: contents,
+		Icon:     "Note",
+		Color:    []float64{1, 1, 0},
+		// PDF/A requires notes to keep their size and orientation
+		Flags: AnnotationPrint | AnnotationNoZoom | AnnotationNoRotate,
+	}
+}
+
+// NewFreeTextAnnotation returns an annotation which shows the contents
+// directly on the page in the face at the given size.
+func NewFreeTextAnnotation(rect [4]float64, contents string, face *Face, size float64) Annotation {
+	return Annotation{
+		Subtype:     "FreeText",
+		Rect:        rect,
+		Contents:    contents,
+		Face:        face,
+		FontSize:    size,
+		BorderWidth: 1,
+		Flags:       AnnotationPrint,
+	}
+}
+
+// NewHighlightAnnotation returns an annotation which highlights the text in
+// the quadrilaterals. See RectQuadPoints.
+func NewHighlightAnnotation(quadPoints []float64) Annotation {
+	return newTextMarkupAnnotation("Highlight", quadPoints, []float64{1, 1, 0})
+}
+
+// NewUnderlineAnnotation returns an annotation which underlines the text in
+// the quadrilaterals.
+func NewUnderlineAnnotation(quadPoints []float64) Annotation {
+	return newTextMarkupAnnotation("Underline", quadPoints, []float64{0, 0, 1})
+}
+
+// NewStrikeOutAnnotation returns an annotation which strikes out the text in
+// the quadrilaterals.
+func NewStrikeOutAnnotation(quadPoints []float64) Annotation {
+	return newTextMarkupAnnotation("StrikeOut", quadPoints, []float64{1, 0, 0})
+}
+
+// NewSquigglyAnnotation returns an annotation which underlines the text in
+// the quadrilaterals with a jagged line.
+func NewSquigglyAnnotation(quadPoints []float64) Annotation {
+	return newTextMarkupAnnotation("Squiggly", quadPoints, []float64{1, 0, 0})
+}
+
+func newTextMarkupAnnotation(subtype Name, quadPoints []float64, color []float64) Annotation {
+	return Annotation{
+		Subtype:    subtype,
+		Rect:       boundingBox(quadPoints, 0),
+		QuadPoints: quadPoints,
+		Color:      color,
+		Flags:      AnnotationPrint,
+	}
+}
+
+// RectQuadPoints returns the quad points for text markup annotations that
+// cover the rectangles (x1, y1, x2, y2), for example one rectangle per line
+// of text.
+func RectQuadPoints(rects ...[4]float64) []float64 {
+	qp := make([]float64, 0, 8*len(rects))
+	for _, r := range rects {
+		x1, y1 := min(r[0], r[2]), min(r[1], r[3])
+		x2, y2 := max(r[0], r[2]), max(r[1], r[3])
+		qp = append(qp, x1, y2, x2, y2, x1, y1, x2, y1)
+	}
+	return qp
+}
+
+// NewSquareAnnotation returns a rectangle annotation.
+func NewSquareAnnotation(rect [4]float64) Annotation {
+	return newShapeAnnotation("Square", rect)
+}
+
+// NewCircleAnnotation returns an ellipse annotation which fits into the
+// rectangle.
+func NewCircleAnnotation(rect [4]float64) Annotation {
+	return newShapeAnnotation("Circle", rect)
+}
+
+func newShapeAnnotation(subtype Name, rect [4]float64) Annotation {
+	return Annotation{
+		Subtype:     subtype,
+		Rect:        rect,
+		Color:       []float64{1, 0, 0},
+		BorderWidth: 1,
+		Flags:       AnnotationPrint,
+	}
+}
+
+// NewLineAnnotation returns a line from (x1, y1) to (x2, y2).
+func NewLineAnnotation(x1, y1, x2, y2 float64) Annotation {
+	return newVerticesAnnotation("Line", []float64{x1, y1, x2, y2})
+}
+
+// NewPolygonAnnotation returns a closed polygon. The vertices are x y pairs.
+func NewPolygonAnnotation(vertices []float64) Annotation {
+	return newVerticesAnnotation("Polygon", vertices)
+}
+
+// NewPolyLineAnnotation returns an open polygon. The vertices are x y pairs.
+func NewPolyLineAnnotation(vertices []float64) Annotation {
+	return newVerticesAnnotation("PolyLine", vertices)
+}
+
+func newVerticesAnnotation(subtype Name, vertices []float64) Annotation {
+	a := newShapeAnnotation(subtype, boundingBox(vertices, 1))
+	a.Vertices = vertices
+	return a
+}
+
+// NewInkAnnotation returns a freehand drawing. Each path is a list of x y
+// pairs.
+func NewInkAnnotation(paths [][]float64) Annotation {
+	a := newShapeAnnotation("Ink", boundingBox(slices.Concat(paths...), 1))
+	a.InkList = paths
+	return a
+}
+
+// NewStampAnnotation returns a rubber stamp such as Approved or Draft. The
+// name of the icon is printed in the stamp if the annotation has a Face.
+func NewStampAnnotation(rect [4]float64, icon Name) Annotation {
+	return Annotation{
+		Subtype: "Stamp",
+		Rect:    rect,
+		Icon:    icon,
+		Color:   []float64{1, 0, 0},
+		Flags:   AnnotationPrint,
+	}
+}
+
+// NewCaretAnnotation returns a caret which marks a text insertion.
+func NewCaretAnnotation(rect [4]float64) Annotation {
+	return Annotation{
+		Subtype: "Caret",
+		Rect:    rect,
+		Color:   []float64{0, 0, 1},
+		Flags:   AnnotationPrint,
+	}
+}
+
+// NewPopupAnnotation returns a pop-up window for the Popup field of a markup
+// annotation.
+func NewPopupAnnotation(rect [4]float64, open bool) *Annotation {
+	return &Annotation{
+		Subtype: "Popup",
+		Rect:    rect,
+		Open:    open,
+	}
+}
+
+// boundingBox returns the bounding box of the x y pairs, enlarged by margin.
+func boundingBox(points []float64, margin float64) [4]float64 {
+	if len(points) < 2 {
+		return [4]float64{}
+	}
+	r := [4]float64{points[0], points[1], points[0], points[1]}
+	for i := 0; i+1 < len(points); i += 2 {
+		r[0], r[2] = min(r[0], points[i]), max(r[2], points[i])
+		r[1], r[3] = min(r[1], points[i+1]), max(r[3], points[i+1])
+	}
+	return [4]float64{r[0] - margin, r[1] - margin, r[2] + margin, r[3] + margin}
+}
+
+// numberArray returns the numbers as a PDF array.
+func numberArray(nums []float64) string {
+	s := make([]string, len(nums))
+	for i, n := range nums {
+		s[i] = fmtPDFFloat(n)
+	}
+	return "[" + strings.Join(s, " ") + "]"
+}
+
+// checkAnnotation makes sure that the markup annotation has the entries its
+// subtype needs.
+func (pw *PDF) checkAnnotation(a *Annotation) error {
+	if !slices.Contains(markupSubtypes, a.Subtype) {
+		return nil
+	}
+	for _, c := range [][]float64{a.Color, a.InteriorColor} {
+		switch len(c) {
+		case 0, 1, 3, 4:
+		default:
+			return fmt.Errorf("pdf: %s annotation colour needs 1, 3 or 4 components", a.Subtype)
+		}
+	}
+	if a.Opacity != nil && (*a.Opacity < 0 || *a.Opacity > 1) {
+		return fmt.Errorf("pdf: %s annotation opacity %s is not between 0 and 1", a.Subtype, fmtPDFFloat(*a.Opacity))
+	}
+	switch a.Subtype {
+	case "FreeText":
+		if a.Face == nil {
+			return fmt.Errorf("pdf: FreeText annotation needs a face")
+		}
+	case "Highlight", "Underline", "StrikeOut", "Squiggly":
+		if len(a.QuadPoints) == 0 || len(a.QuadPoints)%8 != 0 {
+			return fmt.Errorf("pdf: %s annotation needs quad points, eight numbers each", a.Subtype)
+		}
+	case "Line":
+		if len(a.Vertices) != 4 {
+			return fmt.Errorf("pdf: Line annotation needs two end points")
+		}
+	case "Polygon", "PolyLine":
+		if len(a.Vertices) < 4 || len(a.Vertices)%2 != 0 {
+			return fmt.Errorf("pdf: %s annotation needs at least two vertices", a.Subtype)
+		}
+	case "Ink":
+		if len(a.InkList) == 0 {
+			return fmt.Errorf("pdf: Ink annotation without paths")
+		}
+		for _, path := range a.InkList {
+			if len(path) < 2 || len(path)%2 != 0 {
+				return fmt.Errorf("pdf: Ink annotation path needs x y pairs")
+			}
+		}
+	}
+	if pa := pw.PDFA; pa != nil {
+		if pa.Level == PDFA1b && a.Opacity != nil && *a.Opacity < 1 {
+			pw.conformanceErrors = append(pw.conformanceErrors, fmt.Errorf("pdf: PDF/A-1 does not allow transparent %s annotations", a.Subtype))
+		}
+		if a.Color != nil || a.InteriorColor != nil {
+			if n, err := iccComponents(pa.ICCProfile); err == nil && n != 3 {
+				pw.conformanceErrors = append(pw.conformanceErrors, fmt.Errorf("pdf: PDF/A only allows annotation colours with an RGB output intent"))
+			}
+		}
+	}
+	return nil
+}
+
+// writeAnnotation writes the annotation and its pop-up window and returns
+// the references for the /Annots array of the page.
+func (pw *PDF) writeAnnotation(annot *Annotation) ([]string, error) {
+	if err := pw.checkAnnotation(annot); err != nil {
+		return nil, err
+	}
+	var annotObj *Object
+	if annot.Objectnumber != 0 {
+		annotObj = pw.NewObjectWithNumber(annot.Objectnumber)
+	} else {
+		annotObj = pw.NewObject()
+	}
+	annotDict := Dict{
+		"Type":    "/Annot",
+		"Subtype": annot.Subtype.String(),
+		"Rect":    fmt.Sprintf("[%s %s %s %s]", FloatToPoint(annot.Rect[0]), FloatToPoint(annot.Rect[1]), FloatToPoint(annot.Rect[2]), FloatToPoint(annot.Rect[3])),
+	}
+	if annot.OnActivate != nil {
+		if annot.Action != "" {
+			return nil, fmt.Errorf("pdf: annotation has both Action and OnActivate")
+		}
+		if err := pw.checkAction(annot.OnActivate); err != nil {
+			return nil, err
+		}
+		annotDict["A"] = annot.OnActivate
+	} else if annot.Action != "" {
+		annotDict["A"] = annot.Action
+	}
+	if len(annot.AdditionalActions) > 0 {
+		if err := pw.checkAdditionalActions(annot.AdditionalActions, annotationTriggers); err != nil {
+			return nil, err
+		}
+		annotDict["AA"] = annot.AdditionalActions
+	}
+	if annot.Flags != 0 {
+		annotDict["F"] = strconv.Itoa(annot.Flags)
+	}
+	if annot.Contents != "" {
+		annotDict["Contents"] = stringToPDF(annot.Contents)
+	}
+	if !annot.Modified.IsZero() {
+		annotDict["M"] = pdfDate(annot.Modified)
+	}
+	if annot.Open {
+		annotDict["Open"] = "true"
+	}
+	if annot.OptionalContent != nil {
+		annotDict["OC"] = annot.OptionalContent.ocObjectnumber().Ref()
+	}
+	if slices.Contains(markupSubtypes, annot.Subtype) {
+		annot.markupEntries(annotDict)
+		if annot.Dictionary["AP"] == nil {
+			ap, err := pw.writeAnnotationAppearance(annot)
+			if err != nil {
+				return nil, err
+			}
+			annotDict["AP"] = "<< /N " + ap + " >>"
+		}
+	}
+	refs := []string{annotObj.ObjectNumber.Ref()}
+	if annot.Popup != nil {
+		// the caller's pop-up is left untouched
+		popup := *annot.Popup
+		if popup.Objectnumber == 0 {
+			popup.Objectnumber = pw.NextObject()
+		}
+		popup.Subtype = "Popup"
+		popup.Dictionary = maps.Clone(popup.Dictionary)
+		if popup.Dictionary == nil {
+			popup.Dictionary = Dict{}
+		}
+		popup.Dictionary["Parent"] = annotObj.ObjectNumber.Ref()
+		annotDict["Popup"] = popup.Objectnumber.Ref()
+		popupRefs, err := pw.writeAnnotation(&popup)
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, popupRefs...)
+	}
+	maps.Copy(annotDict, annot.Dictionary)
+	if pw.PDFA != nil && annotDict["F"] == nil && annot.Subtype != "Popup" {
+		// PDF/A requires annotations to be printed
+		annotDict["F"] = "4"
+	}
+
+	annotObj.Dict(annotDict)
+	if err := annotObj.Save(); err != nil {
+		return nil, err
+	}
+	return refs, nil
+}
+
+// markupEntries adds the entries of markup annotations to the annotation
+// dictionary.
+func (a *Annotation) markupEntries(d Dict) {
+	if a.Author != "" {
+		d["T"] = stringToPDF(a.Author)
+	}
+	if a.Color != nil {
+		d["C"] = colorArray(a.Color)
+	}
+	if a.InteriorColor != nil {
+		d["IC"] = colorArray(a.InteriorColor)
+	}
+	if a.Opacity != nil {
+		d["CA"] = fmtPDFFloat(*a.Opacity)
+	}
+	if a.InReplyTo != 0 {
+		d["IRT"] = a.InReplyTo.Ref()
+	}
+	if a.Icon != "" {
+		d["Name"] = a.Icon.String()
+	}
+	switch a.Subtype {
+	case "FreeText", "Square", "Circle", "Line", "Polygon", "PolyLine", "Ink":
+		d["BS"] = Dict{"W": fmtPDFFloat(a.BorderWidth), "S": "/S"}
+	}
+	switch a.Subtype {
+	case "FreeText":
+		d["DA"] = stringToPDF(fmt.Sprintf("%s %s Tf 0 g", a.Face.InternalName(), fmtPDFFloat(a.fontSize())))
+	case "Highlight", "Underline", "StrikeOut", "Squiggly":
+		d["QuadPoints"] = numberArray(a.QuadPoints)
+	case "Line":
+		d["L"] = numberArray(a.Vertices)
+	case "Polygon", "PolyLine":
+		d["Vertices"] = numberArray(a.Vertices)
+	case "Ink":
+		paths := make([]string, len(a.InkList))
+		for i, path := range a.InkList {
+			paths[i] = numberArray(path)
+		}
+		d["InkList"] = "[" + strings.Join(paths, " ") + "]"
+	}
+}
+
+func (a *Annotation) fontSize() float64 {
+	if a.FontSize > 0 {
+		return a.FontSize
+	}
+	return 12
+}
+
+// writeAnnotationAppearance writes the normal appearance of the markup
+// annotation and returns its reference. The bounding box of the form is the
+// rectangle of the annotation, so the drawing operators use page
+// coordinates.
+func (pw *PDF) writeAnnotationAppearance(a *Annotation) (string, error) {
+	var b strings.Builder
+	resources := Dict{}
+	gs := &ExtGState{}
+	if a.Opacity != nil {
+		gs.StrokeAlpha = a.Opacity
+		gs.FillAlpha = a.Opacity
+	}
+	if a.Subtype == "Highlight" && (pw.PDFA == nil || pw.PDFA.Level != PDFA1b) {
+		// keep the text under the highlight readable
+		gs.BlendMode = "Multiply"
+	}
+	if *gs != (ExtGState{}) {
+		num, err := pw.extGStateObject(gs)
+		if err != nil {
+			return "", err
+		}
+		resources["ExtGState"] = Dict{"GS0": num.Ref()}
+		b.WriteString("/GS0 gs\n")
+	}
+	if a.Face != nil {
+		resources["Font"] = Dict{Name(a.Face.InternalName()): a.Face.fontobject.ObjectNumber.Ref()}
+	}
+	a.appearance(&b)
+
+	x1, y1 := min(a.Rect[0], a.Rect[2]), min(a.Rect[1], a.Rect[3])
+	x2, y2 := max(a.Rect[0], a.Rect[2]), max(a.Rect[1], a.Rect[3])
+	ap := pw.NewObject()
+	ap.Dictionary = Dict{
+		"Type":    "/XObject",
+		"Subtype": "/Form",
+		"BBox":    numberArray([]float64{x1, y1, x2, y2}),
+	}
+	if len(resources) > 0 {
+		ap.Dictionary["Resources"] = resources
+	}
+	ap.Data.WriteString(b.String())
+	ap.ForceStream = true
+	ap.SetCompression(9)
+	if err := ap.Save(); err != nil {
+		return "", err
+	}
+	return ap.ObjectNumber.Ref(), nil
+}
+
+// appearance writes the drawing operators of the markup annotation.
+func (a *Annotation) appearance(b *strings.Builder) {
+	f := fmtPDFFloat
+	x1, y1 := min(a.Rect[0], a.Rect[2]), min(a.Rect[1], a.Rect[3])
+	x2, y2 := max(a.Rect[0], a.Rect[2]), max(a.Rect[1], a.Rect[3])
+	w, h := x2-x1, y2-y1
+	color := a.Color
+	if color == nil {
+		color = []float64{0}
+	}
+	bw := a.BorderWidth
+	// paint fills and strokes the path with the interior and the border
+	// colour.
+	paint := func(closed bool) {
+		switch {
+		case a.InteriorColor != nil && closed:
+			b.WriteString("b\n")
+		case closed:
+			b.WriteString("s\n")
+		default:
+			b.WriteString("S\n")
+		}
+	}
+	fmt.Fprintf(b, "%s\n%s w\n", colorOperator(color, true), f(bw))
+	if a.InteriorColor != nil {
+		b.WriteString(colorOperator(a.InteriorColor, false) + "\n")
+	}
+	switch a.Subtype {
+	case "Text":
+		fmt.Fprintf(b, "%s\n0 G\n1 w\n%s %s %s %s re B\n", colorOperator(color, false), f(x1+0.5), f(y1+0.5), f(w-1), f(h-1))
+		for i := 1; i <= 3; i++ {
+			y := y1 + h*float64(i)/4
+			fmt.Fprintf(b, "%s %s m %s %s l\n", f(x1+w*0.2), f(y), f(x2-w*0.2), f(y))
+		}
+		b.WriteString("S\n")
+	case "FreeText":
+		if a.Color != nil {
+			fmt.Fprintf(b, "%s\n%s %s %s %s re f\n", colorOperator(a.Color, false), f(x1), f(y1), f(w), f(h))
+		}
+		if bw > 0 {
+			fmt.Fprintf(b, "0 G\n%s %s %s %s re S\n", f(x1+bw/2), f(y1+bw/2), f(w-bw), f(h-bw))
+		}
+		size := a.fontSize()
+		padding := 2 + bw
+		upem := float64(a.Face.UnitsPerEM)
+		asc := float64(a.Face.face.Ascender()) / upem * size
+		desc := float64(a.Face.face.Descender()) / upem * size
+		leading := (asc - desc) * 1.15
+		fmt.Fprintf(b, "q\n%s %s %s %s re W n\nBT\n%s %s Tf\n0 g\n", f(x1+bw), f(y1+bw), f(w-2*bw), f(h-2*bw), a.Face.InternalName(), f(size))
+		for i, line := range wrapText(a.Face, a.Contents, size, w-2*padding) {
+			hex, _ := encodeText(a.Face, line, size)
+			fmt.Fprintf(b, "1 0 0 1 %s %s Tm\n%s Tj\n", f(x1+padding), f(y2-padding-asc-float64(i)*leading), hex)
+		}
+		b.WriteString("ET\nQ\n")
+	case "Highlight", "Underline", "StrikeOut", "Squiggly":
+		for q := 0; q+7 < len(a.QuadPoints); q += 8 {
+			qp := a.QuadPoints[q : q+8]
+			// upper left, upper right, lower left, lower right
+			ulx, uly, urx, ury, llx, lly, lrx, lry := qp[0], qp[1], qp[2], qp[3], qp[4], qp[5], qp[6], qp[7]
+			// vx, vy is the vector from the bottom to the top of the text
+			vx, vy := ulx-llx, uly-lly
+			height := math.Hypot(vx, vy)
+			switch a.Subtype {
+			case "Highlight":
+				fmt.Fprintf(b, "%s\n%s %s m %s %s l %s %s l %s %s l h f\n", colorOperator(color, false), f(ulx), f(uly), f(urx), f(ury), f(lrx), f(lry), f(llx), f(lly))
+			case "Underline", "StrikeOut":
+				pos := 0.07
+				if a.Subtype == "StrikeOut" {
+					pos = 0.45
+				}
+				fmt.Fprintf(b, "%s w\n%s %s m %s %s l S\n", f(height/14), f(llx+vx*pos), f(lly+vy*pos), f(lrx+vx*pos), f(lry+vy*pos))
+			case "Squiggly":
+				length := math.Hypot(lrx-llx, lry-lly)
+				if length == 0 || height == 0 {
+					continue
+				}
+				dx, dy := (lrx-llx)/length, (lry-lly)/length
+				step := height / 6
+				fmt.Fprintf(b, "%s w\n%s %s m\n", f(height/20), f(llx+vx*0.02), f(lly+vy*0.02))
+				for i := 1; float64(i)*step <= length; i++ {
+					up := 0.02
+					if i%2 == 1 {
+						up = 0.12
+					}
+					d := float64(i) * step
+					fmt.Fprintf(b, "%s %s l\n", f(llx+dx*d+vx*up), f(lly+dy*d+vy*up))
+				}
+				b.WriteString("S\n")
+			}
+		}
+	case "Square":
+		fmt.Fprintf(b, "%s %s %s %s re\n", f(x1+bw/2), f(y1+bw/2), f(w-bw), f(h-bw))
+		paint(true)
+	case "Circle":
+		b.WriteString(ellipsePath(x1+w/2, y1+h/2, (w-bw)/2, (h-bw)/2))
+		paint(true)
+	case "Line", "Polygon", "PolyLine":
+		b.WriteString("1 J\n1 j\n")
+		writePath(b, a.Vertices)
+		paint(a.Subtype == "Polygon")
+	case "Ink":
+		b.WriteString("1 J\n1 j\n")
+		for _, path := range a.InkList {
+			writePath(b, path)
+		}
+		paint(false)
+	case "Stamp":
+		fmt.Fprintf(b, "2 w\n%s %s %s %s re S\n", f(x1+1), f(y1+1), f(w-2), f(h-2))
+		fmt.Fprintf(b, "1 w\n%s %s %s %s re S\n", f(x1+4), f(y1+4), f(w-8), f(h-8))
+		if a.Face != nil && a.Icon != "" {
+			text := strings.ToUpper(strings.TrimPrefix(string(a.Icon), "/"))
+			size := a.FontSize
+			if size == 0 {
+				size = h / 2
+				if tw := textWidth(a.Face, text, size); tw > w-12 {
+					size *= (w - 12) / tw
+				}
+			}
+			hex, tw := encodeText(a.Face, text, size)
+			upem := float64(a.Face.UnitsPerEM)
+			capHeight := float64(a.Face.face.Ascender()) / upem * size * 0.7
+			fmt.Fprintf(b, "BT\n%s\n%s %s Tf\n1 0 0 1 %s %s Tm\n%s Tj\nET\n", colorOperator(color, false), a.Face.InternalName(), f(size), f(x1+(w-tw)/2), f(y1+(h-capHeight)/2), hex)
+		}
+	case "Caret":
+		cx := x1 + w/2
+		fmt.Fprintf(b, "%s\n%s %s m %s %s %s %s %s %s c %s %s %s %s %s %s c h f\n", colorOperator(color, false),
+			f(x1), f(y1), f(cx), f(y1), f(cx), f(y2), f(cx), f(y2),
+			f(cx), f(y2), f(cx), f(y1), f(x2), f(y1))
+	}
+}
+
+// writePath writes the x y pairs as a path of straight lines.
+func writePath(b *strings.Builder, points []float64) {
+	for i := 0; i+1 < len(points); i += 2 {
+		op := "l"
+		if i == 0 {
+			op = "m"
+		}
+		fmt.Fprintf(b, "%s %s %s\n", fmtPDFFloat(points[i]), fmtPDFFloat(points[i+1]), op)
+	}
+}
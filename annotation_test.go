@@ -0,0 +1,174 @@
+package pdf
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMarkupAnnotations(t *testing.T) {
+	pw, buf := newTestPDF()
+	page := pw.AddPage(pw.NewObject(), 0)
+	note := NewTextAnnotation([4]float64{10, 10, 30, 30}, "Please check")
+	note.Author = "Jane"
+	note.Modified = time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	note.Objectnumber = pw.NextObject()
+	note.Popup = NewPopupAnnotation([4]float64{40, 10, 200, 100}, true)
+	reply := NewTextAnnotation([4]float64{10, 10, 30, 30}, "Done")
+	reply.InReplyTo = note.Objectnumber
+	highlight := NewHighlightAnnotation(RectQuadPoints([4]float64{100, 700, 300, 712}))
+	opacity := 0.5
+	highlight.Opacity = &opacity
+	square := NewSquareAnnotation([4]float64{50, 50, 150, 100})
+	square.InteriorColor = []float64{0, 1, 0}
+	page.Annotations = append(page.Annotations,
+		note,
+		reply,
+		highlight,
+		NewUnderlineAnnotation(RectQuadPoints([4]float64{100, 680, 300, 692})),
+		NewStrikeOutAnnotation(RectQuadPoints([4]float64{100, 660, 300, 672})),
+		NewSquigglyAnnotation(RectQuadPoints([4]float64{100, 640, 300, 652})),
+		square,
+		NewCircleAnnotation([4]float64{50, 150, 150, 200}),
+		NewLineAnnotation(10, 300, 200, 350),
+		NewPolygonAnnotation([]float64{10, 400, 60, 450, 110, 400}),
+		NewPolyLineAnnotation([]float64{10, 500, 60, 550, 110, 500}),
+		NewInkAnnotation([][]float64{{300, 300, 310, 320, 320, 300}}),
+		NewStampAnnotation([4]float64{300, 400, 450, 450}, "Approved"),
+		NewCaretAnnotation([4]float64{300, 500, 310, 510}),
+	)
+	if err := pw.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"/Subtype /Text",
+		"/Contents (Please check)",
+		"/T (Jane)",
+		"/M (D:20250301120000+00'00')",
+		"/Name /Note",
+		"/F 28",
+		"/Subtype /Popup",
+		fmt.Sprintf("/Parent %s", note.Objectnumber.Ref()),
+		"/Open true",
+		fmt.Sprintf("/IRT %s", note.Objectnumber.Ref()),
+		"/Subtype /Highlight",
+		"/QuadPoints [100 712 300 712 100 700 300 700]",
+		"/CA 0.5",
+		"/BM /Multiply",
+		"/Subtype /Underline",
+		"/Subtype /StrikeOut",
+		"/Subtype /Squiggly",
+		"/Subtype /Square",
+		"/IC [0 1 0]",
+		"/BS <<",
+		"/Subtype /Circle",
+		"/L [10 300 200 350]",
+		"/Vertices [10 400 60 450 110 400]",
+		"/Subtype /PolyLine",
+		"/InkList [[300 300 310 320 320 300]]",
+		"/Name /Approved",
+		"/Subtype /Caret",
+		"/AP << /N ",
+		"/BBox [100 700 300 712]",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q", want)
+		}
+	}
+	if got := strings.Count(out, "/AP << /N "); got != 14 {
+		t.Errorf("%d appearance streams, want 14", got)
+	}
+	if !regexp.MustCompile(`/Popup \d+ 0 R`).MatchString(out) {
+		t.Error("note has no reference to its pop-up")
+	}
+	if p := note.Popup; p.Objectnumber != 0 || p.Dictionary != nil {
+		t.Error("writing the annotation modified the caller's pop-up")
+	}
+}
+
+func TestMarkupAnnotationAppearance(t *testing.T) {
+	for _, tc := range []struct {
+		a    Annotation
+		want string
+	}{
+		{NewHighlightAnnotation(RectQuadPoints([4]float64{0, 0, 100, 14})), "0 14 m 100 14 l 100 0 l 0 0 l h f"},
+		{NewUnderlineAnnotation(RectQuadPoints([4]float64{0, 0, 100, 14})), "0 0.98 m 100 0.98 l S"},
+		{NewSquareAnnotation([4]float64{0, 0, 100, 50}), "0.5 0.5 99 49 re\ns"},
+		{NewLineAnnotation(0, 0, 100, 50), "0 0 m\n100 50 l\nS"},
+		{NewPolygonAnnotation([]float64{0, 0, 50, 50, 100, 0}), "100 0 l\ns"},
+	} {
+		var b strings.Builder
+		tc.a.appearance(&b)
+		if !strings.Contains(b.String(), tc.want) {
+			t.Errorf("%s appearance %q does not contain %q", tc.a.Subtype, b.String(), tc.want)
+		}
+	}
+}
+
+func TestFreeTextAnnotation(t *testing.T) {
+	pw, buf := newTestPDF()
+	face := loadTestFace(t, pw)
+	page := pw.AddPage(pw.NewObject(), 0)
+	page.Annotations = append(page.Annotations, NewFreeTextAnnotation([4]float64{10, 10, 110, 60}, "Some words in a box", face, 10))
+	if err := pw.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"/Subtype /FreeText",
+		fmt.Sprintf("/DA (%s 10 Tf 0 g)", face.InternalName()),
+		fmt.Sprintf("%s %s", face.InternalName(), face.fontobject.ObjectNumber.Ref()),
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q", want)
+		}
+	}
+}
+
+func TestMarkupAnnotationErrors(t *testing.T) {
+	opacity := 2.0
+	for _, a := range []Annotation{
+		NewHighlightAnnotation(nil),
+		NewUnderlineAnnotation([]float64{1, 2, 3}),
+		NewPolygonAnnotation([]float64{1, 2}),
+		NewInkAnnotation(nil),
+		NewInkAnnotation([][]float64{{1, 2, 3}}),
+		NewFreeTextAnnotation([4]float64{0, 0, 10, 10}, "x", nil, 10),
+		{Subtype: "Line", Vertices: []float64{1, 2, 3}},
+		{Subtype: "Square", Color: []float64{1, 0}},
+		{Subtype: "Square", Opacity: &opacity},
+	} {
+		pw, _ := newTestPDF()
+		page := pw.AddPage(pw.NewObject(), 0)
+		page.Annotations = append(page.Annotations, a)
+		if err := pw.Finish(); err == nil {
+			t.Errorf("expected an error for %s annotation", a.Subtype)
+		}
+	}
+}
+
+func TestMarkupAnnotationPDFA(t *testing.T) {
+	highlight := func(pw *PDF) {
+		page := pw.AddPage(pw.NewObject(), 0)
+		a := NewHighlightAnnotation(RectQuadPoints([4]float64{0, 0, 100, 14}))
+		opacity := 0.5
+		a.Opacity = &opacity
+		page.Annotations = append(page.Annotations, a)
+	}
+	out, err := writePDFATestPDF(t, &PDFA{Level: PDFA2b, ICCProfile: testICCProfile("RGB ")}, highlight)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "/F 4") {
+		t.Error("PDF/A annotation is not printed")
+	}
+	if _, err = writePDFATestPDF(t, &PDFA{Level: PDFA1b, ICCProfile: testICCProfile("RGB ")}, highlight); err == nil {
+		t.Error("expected an error for a transparent annotation in PDF/A-1")
+	}
+	if _, err = writePDFATestPDF(t, &PDFA{Level: PDFA2b, ICCProfile: testICCProfile("CMYK")}, highlight); err == nil {
+		t.Error("expected an error for annotation colours with a CMYK output intent")
+	}
+}
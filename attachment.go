@@ -0,0 +1,198 @@
+package pdf
+
+import (
+	"crypto/md5"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// AFRelationship describes how an associated file relates to the PDF
+// content it is attached to (PDF 2.0 and PDF/A-3).
+type AFRelationship string
+
+// The relationships defined in ISO 32000-2.
+const (
+	AFSource           AFRelationship = "Source"
+	AFData             AFRelationship = "Data"
+	AFAlternative      AFRelationship = "Alternative"
+	AFSupplement       AFRelationship = "Supplement"
+	AFEncryptedPayload AFRelationship = "EncryptedPayload"
+	AFFormData         AFRelationship = "FormData"
+	AFSchema           AFRelationship = "Schema"
+	AFUnspecified      AFRelationship = "Unspecified"
+)
+
+// Attachment is a file embedded in the PDF. Use PDF.AttachFile for a
+// document level attachment or PDF.EmbedFile and Page.AttachFile for a file
+// attachment annotation.
+type Attachment struct {
+	// Name is the file name shown by the PDF viewer. It is also the key in
+	// the /EmbeddedFiles name tree.
+	Name        string
+	Description string
+	// MIMEType is the media type of the file such as "text/xml". PDF/A-3
+	// requires it.
+	MIMEType         string
+	Data             []byte
+	CreationDate     time.Time
+	ModificationDate time.Time
+	// Relationship is the /AFRelationship of the file. Files with a
+	// relationship are associated files and are listed in the /AF array of
+	// the catalog or the annotation. For PDF 2.0 and PDF/A-3 files every
+	// attachment is an associated file and the default is AFUnspecified.
+	Relationship AFRelationship
+	// Uncompressed stores the file data as is. Useful for files that are
+	// compressed already.
+	Uncompressed bool
+	filespec     Objectnumber
+}
+
+// Filespec returns the object number of the file specification dictionary
+// of an embedded file, 0 if the file has not been embedded yet.
+func (a *Attachment) Filespec() Objectnumber {
+	return a.filespec
+}
+
+// relationship returns the /AFRelationship to write, or "" for plain
+// attachments.
+func (pw *PDF) relationship(a *Attachment) AFRelationship {
+	if a.Relationship == "" && (pw.version >= Version20 || pw.PDFA != nil && pw.PDFA.Level == PDFA3b) {
+		return AFUnspecified
+	}
+	return a.Relationship
+}
+
+// EmbedFile writes the embedded file stream and its file specification. The
+// file is neither listed in the document's attachments nor visible on a page
+// unless it is referenced, for example by Page.AttachFile.
+func (pw *PDF) EmbedFile(a *Attachment) error {
+	if a.filespec != 0 {
+		return nil
+	}
+	if a.Name == "" {
+		return fmt.Errorf("pdf: attachment without a file name")
+	}
+	if pw.PDFA != nil {
+		switch {
+		case pw.PDFA.Level == PDFA1b:
+			pw.conformanceErrors = append(pw.conformanceErrors, fmt.Errorf("pdf: PDF/A-1 does not allow embedded files (%s)", a.Name))
+		case pw.PDFA.Level == PDFA2b && a.MIMEType != "application/pdf":
+			pw.conformanceErrors = append(pw.conformanceErrors, fmt.Errorf("pdf: PDF/A-2 only allows embedded PDF/A files (%s)", a.Name))
+		case a.MIMEType == "":
+			pw.conformanceErrors = append(pw.conformanceErrors, fmt.Errorf("pdf: PDF/A-3 requires a MIME type for embedded file %s", a.Name))
+		}
+	}
+
+	checksum := md5.Sum(a.Data)
+	params := Dict{
+		"Size":     strconv.Itoa(len(a.Data)),
+		"CheckSum": fmt.Sprintf("<%x>", checksum),
+	}
+	if !a.CreationDate.IsZero() {
+		params["CreationDate"] = pdfDate(a.CreationDate)
+	}
+	if !a.ModificationDate.IsZero() {
+		params["ModDate"] = pdfDate(a.ModificationDate)
+	}
+	ef := pw.NewObject()
+	ef.Dictionary = Dict{
+		"Type":   "/EmbeddedFile",
+		"Params": params,
+	}
+	if a.MIMEType != "" {
+		ef.Dictionary["Subtype"] = Name(a.MIMEType).String()
+	}
+	ef.Data.Write(a.Data)
+	ef.ForceStream = true
+	if !a.Uncompressed {
+		ef.SetCompression(9)
+	}
+	if err := ef.Save(); err != nil {
+		return err
+	}
+
+	fs := pw.NewObject()
+	fs.Dictionary = Dict{
+		"Type": "/Filespec",
+		"F":    stringToPDF(a.Name),
+		"UF":   stringToPDF(a.Name),
+		"EF":   Dict{"F": ef.ObjectNumber.Ref(), "UF": ef.ObjectNumber.Ref()},
+	}
+	if a.Description != "" {
+		fs.Dictionary["Desc"] = stringToPDF(a.Description)
+	}
+	if rel := pw.relationship(a); rel != "" {
+		fs.Dictionary["AFRelationship"] = Name(rel).String()
+	}
+	if err := fs.Save(); err != nil {
+		return err
+	}
+	a.filespec = fs.ObjectNumber
+	return nil
+}
+
+// AttachFile embeds the file and adds it to the document's attachments (the
+// /EmbeddedFiles name tree). Associated files are also added to the /AF
+// array of the catalog, which is what Factur-X and ZUGFeRD invoices need.
+func (pw *PDF) AttachFile(a *Attachment) error {
+	for _, other := range pw.attachments {
+		if other.Name == a.Name {
+			return fmt.Errorf("pdf: duplicate attachment name %q", a.Name)
+		}
+	}
+	if err := pw.EmbedFile(a); err != nil {
+		return err
+	}
+	pw.attachments = append(pw.attachments, a)
+	return nil
+}
+
+// AttachFile adds a file attachment annotation to the page. The attachment
+// is embedded if necessary. Icon is one of Graph, PushPin, Paperclip or Tag
+// and defaults to PushPin.
+func (p *Page) AttachFile(pw *PDF, a *Attachment, rect [4]float64, icon Name) error {
+	if err := pw.EmbedFile(a); err != nil {
+		return err
+	}
+	annot := Annotation{
+		Subtype: "FileAttachment",
+		Rect:    rect,
+		Dictionary: Dict{
+			"FS": a.filespec.Ref(),
+		},
+	}
+	if icon != "" {
+		annot.Dictionary["Name"] = icon.String()
+	}
+	if a.Description != "" {
+		annot.Dictionary["Contents"] = stringToPDF(a.Description)
+	}
+	if pw.relationship(a) != "" {
+		annot.Dictionary["AF"] = "[" + a.filespec.Ref() + "]"
+	}
+	p.Annotations = append(p.Annotations, annot)
+	return nil
+}
+
+// writeAttachments adds the document level attachments to the /Names
+// dictionary and returns the /AF array for the catalog ("" if there are no
+// associated files).
+func (pw *PDF) writeAttachments() string {
+	if len(pw.attachments) == 0 {
+		return ""
+	}
+	files := make(NameTreeData, len(pw.attachments))
+	var af Array
+	for _, a := range pw.attachments {
+		files[String(a.Name)] = a.filespec
+		if pw.relationship(a) != "" {
+			af = append(af, a.filespec)
+		}
+	}
+	pw.names["EmbeddedFiles"] = Dict{"Names": files}
+	if len(af) == 0 {
+		return ""
+	}
+	return Serialize(af)
+}
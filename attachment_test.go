@@ -0,0 +1,102 @@
+package pdf
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAttachFileFacturX(t *testing.T) {
+	pw, buf := newTestPDF()
+	pw.PDFA = &PDFA{Level: PDFA3b, ICCProfile: testICCProfile("RGB ")}
+	invoice := &Attachment{
+		Name:             "factur-x.xml",
+		Description:      "Factur-X invoice",
+		MIMEType:         "text/xml",
+		Data:             []byte("<rsm:CrossIndustryInvoice/>"),
+		ModificationDate: time.Date(2025, 11, 14, 12, 30, 45, 0, time.UTC),
+		Relationship:     AFAlternative,
+	}
+	if err := pw.AttachFile(invoice); err != nil {
+		t.Fatal(err)
+	}
+	if err := pw.AttachFile(&Attachment{Name: "factur-x.xml", MIMEType: "text/xml"}); err == nil {
+		t.Error("expected an error for a duplicate attachment name")
+	}
+	pw.AddPage(pw.NewObject(), 0)
+	if err := pw.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	fs := invoice.Filespec().Ref()
+	for _, want := range []string{
+		"/Type /EmbeddedFile",
+		"/Subtype /text#2fxml",
+		"/Size 27",
+		"/CheckSum <",
+		"/ModDate (D:20251114123045+00'00')",
+		"/Filter /FlateDecode",
+		"/Type /Filespec",
+		"/UF (factur-x.xml)",
+		"/AFRelationship /Alternative",
+		"/AF [ " + fs + " ]",
+		"/EmbeddedFiles",
+		"/Names [(factur-x.xml) " + fs + "]",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q", want)
+		}
+	}
+}
+
+func TestAttachFileAnnotation(t *testing.T) {
+	pw, buf := newTestPDF()
+	page := pw.AddPage(pw.NewObject(), 0)
+	data := &Attachment{Name: "data.csv", MIMEType: "text/csv", Data: []byte("a,b\n1,2\n"), Uncompressed: true}
+	if err := page.AttachFile(pw, data, [4]float64{10, 10, 30, 30}, "Paperclip"); err != nil {
+		t.Fatal(err)
+	}
+	if err := pw.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"/Subtype /FileAttachment",
+		"/FS " + data.Filespec().Ref(),
+		"/Name /Paperclip",
+		"a,b\n1,2\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q", want)
+		}
+	}
+	for _, unwanted := range []string{"/EmbeddedFiles", "/AFRelationship"} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("output contains %q", unwanted)
+		}
+	}
+}
+
+func TestAttachFilePDFA(t *testing.T) {
+	tests := []struct {
+		level PDFALevel
+		att   *Attachment
+		want  string
+	}{
+		{PDFA1b, &Attachment{Name: "a.pdf", MIMEType: "application/pdf"}, "PDF/A-1 does not allow embedded files"},
+		{PDFA2b, &Attachment{Name: "a.xml", MIMEType: "text/xml"}, "PDF/A-2 only allows embedded PDF/A files"},
+		{PDFA3b, &Attachment{Name: "a.xml"}, "requires a MIME type"},
+	}
+	for _, tt := range tests {
+		pw, _ := newTestPDF()
+		pw.PDFA = &PDFA{Level: tt.level, ICCProfile: testICCProfile("RGB ")}
+		if err := pw.AttachFile(tt.att); err != nil {
+			t.Fatal(err)
+		}
+		pw.AddPage(pw.NewObject(), 0)
+		err := pw.Finish()
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("PDF/A-%s: got error %v, want %q", tt.level.part(), err, tt.want)
+		}
+	}
+}
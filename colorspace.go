@@ -0,0 +1,192 @@
+package pdf
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strconv"
+)
+
+// ColorSpace is a device-independent colour space written as an indirect
+// object. Create it with PDF.NewICCBasedColorSpace or PDF.NewCIEColorSpace
+// and use Ref wherever a colour space is expected: in the ColorSpaces of a
+// page or of other resources, as the ColorSpace of a shading or transparency
+// group, or with Imagefile.SetColorSpace.
+type ColorSpace struct {
+	// Objnum is the object number of the colour space array.
+	Objnum     Objectnumber
+	components int
+}
+
+// Ref returns the reference to the colour space.
+func (cs *ColorSpace) Ref() Name {
+	return Name(cs.Objnum.Ref())
+}
+
+// Components returns the number of colour components of the colour space.
+func (cs *ColorSpace) Components() int {
+	return cs.components
+}
+
+// CIEColorSpace describes a CalGray, CalRGB or Lab colour space (Section
+// 8.6.5 of ISO 32000-1).
+type CIEColorSpace struct {
+	// Family is CalGray, CalRGB or Lab.
+	Family Name
+	// WhitePoint is the diffuse white point in CIE XYZ, Y must be 1. For
+	// example [0.9505 1 1.089] for D65.
+	WhitePoint [3]float64
+	// BlackPoint is the diffuse black point in CIE XYZ. The zero value is
+	// the default [0 0 0].
+	BlackPoint [3]float64
+	// Gamma has one value for CalGray and three for CalRGB. Nil means 1.
+	Gamma []float64
+	// Matrix is the linear transformation of the gamma-corrected CalRGB
+	// components to XYZ, nine values column by column. Nil means the
+	// identity.
+	Matrix []float64
+	// Range is amin amax bmin bmax of a Lab colour space. Nil means
+	// [-100 100 -100 100].
+	Range []float64
+}
+
+// dict returns the colour space dictionary and the number of components.
+func (c CIEColorSpace) dict() (Dict, int, error) {
+	var components, gammas int
+	switch c.Family {
+	case "CalGray":
+		components, gammas = 1, 1
+	case "CalRGB":
+		components, gammas = 3, 3
+	case "Lab":
+		components = 3
+	default:
+		return nil, 0, fmt.Errorf("pdf: colour space family %q is not CalGray, CalRGB or Lab", c.Family)
+	}
+	if c.WhitePoint[0] <= 0 || c.WhitePoint[1] != 1 || c.WhitePoint[2] <= 0 {
+		return nil, 0, fmt.Errorf("pdf: colour space white point %s needs positive X and Z and Y = 1", numberArray(c.WhitePoint[:]))
+	}
+	if c.BlackPoint[0] < 0 || c.BlackPoint[1] < 0 || c.BlackPoint[2] < 0 {
+		return nil, 0, fmt.Errorf("pdf: colour space black point %s is negative", numberArray(c.BlackPoint[:]))
+	}
+	d := Dict{"WhitePoint": numberArray(c.WhitePoint[:])}
+	if c.BlackPoint != [3]float64{} {
+		d["BlackPoint"] = numberArray(c.BlackPoint[:])
+	}
+	if c.Gamma != nil {
+		if len(c.Gamma) != gammas {
+			return nil, 0, fmt.Errorf("pdf: %s colour space needs %d gamma values, got %d", c.Family, gammas, len(c.Gamma))
+		}
+		for _, g := range c.Gamma {
+			if g <= 0 {
+				return nil, 0, fmt.Errorf("pdf: colour space gamma %s is not positive", fmtPDFFloat(g))
+			}
+		}
+		if gammas == 1 {
+			d["Gamma"] = fmtPDFFloat(c.Gamma[0])
+		} else {
+			d["Gamma"] = numberArray(c.Gamma)
+		}
+	}
+	if c.Matrix != nil {
+		if c.Family != "CalRGB" || len(c.Matrix) != 9 {
+			return nil, 0, fmt.Errorf("pdf: only CalRGB colour spaces have a matrix of 9 values")
+		}
+		d["Matrix"] = numberArray(c.Matrix)
+	}
+	if c.Range != nil {
+		if c.Family != "Lab" || len(c.Range) != 4 {
+			return nil, 0, fmt.Errorf("pdf: only Lab colour spaces have a range of 4 values")
+		}
+		if err := checkIntervals("Range", c.Range); err != nil {
+			return nil, 0, err
+		}
+		d["Range"] = numberArray(c.Range)
+	}
+	return d, components, nil
+}
+
+// NewCIEColorSpace writes a CalGray, CalRGB or Lab colour space.
+func (pw *PDF) NewCIEColorSpace(c CIEColorSpace) (*ColorSpace, error) {
+	d, n, err := c.dict()
+	if err != nil {
+		return nil, err
+	}
+	obj := pw.NewObject()
+	obj.Array = []any{c.Family.String(), d}
+	if err = obj.Save(); err != nil {
+		return nil, err
+	}
+	cs := &ColorSpace{Objnum: obj.ObjectNumber, components: n}
+	pw.colorSpaces = append(pw.colorSpaces, cs)
+	return cs, nil
+}
+
+// defaultAlternate returns the device colour space with n components.
+func defaultAlternate(n int) Name {
+	switch n {
+	case 1:
+		return "/DeviceGray"
+	case 4:
+		return "/DeviceCMYK"
+	}
+	return "/DeviceRGB"
+}
+
+// iccProfileObject writes the ICC profile stream unless the same profile
+// with the same alternate colour space has been written before. It returns
+// the object number of the stream and the number of colour components.
+func (pw *PDF) iccProfileObject(profile []byte, alternate Name) (Objectnumber, int, error) {
+	n, err := iccComponents(profile)
+	if err != nil {
+		return 0, 0, err
+	}
+	if alternate == "" {
+		alternate = defaultAlternate(n)
+	}
+	if m := pw.colorSpaceComponents(alternate); m > 0 && m != n {
+		return 0, 0, fmt.Errorf("pdf: ICC profile has %d components, alternate colour space %s has %d", n, alternate, m)
+	}
+	key := fmt.Sprintf("%x %s", sha256.Sum256(profile), alternate)
+	if num, ok := pw.iccProfiles[key]; ok {
+		return num, n, nil
+	}
+	obj := pw.NewObject()
+	obj.Dictionary = Dict{"N": strconv.Itoa(n), "Alternate": string(alternate)}
+	obj.Data.Write(profile)
+	obj.SetCompression(9)
+	if err = obj.Save(); err != nil {
+		return 0, 0, err
+	}
+	if pw.iccProfiles == nil {
+		pw.iccProfiles = make(map[string]Objectnumber)
+	}
+	pw.iccProfiles[key] = obj.ObjectNumber
+	return obj.ObjectNumber, n, nil
+}
+
+// NewICCBasedColorSpace embeds the ICC profile and returns an ICCBased
+// colour space. The alternate colour space is used by viewers which do not
+// support the profile; empty selects DeviceGray, DeviceRGB or DeviceCMYK by
+// the number of components. Identical profiles are embedded only once, also
+// if they are used for the PDF/A output intent.
+func (pw *PDF) NewICCBasedColorSpace(profile []byte, alternate Name) (*ColorSpace, error) {
+	num, n, err := pw.iccProfileObject(profile, alternate)
+	if err != nil {
+		return nil, err
+	}
+	if cs, ok := pw.iccColorSpaces[num]; ok {
+		return cs, nil
+	}
+	obj := pw.NewObject()
+	obj.Array = []any{"/ICCBased", num.Ref()}
+	if err = obj.Save(); err != nil {
+		return nil, err
+	}
+	cs := &ColorSpace{Objnum: obj.ObjectNumber, components: n}
+	pw.colorSpaces = append(pw.colorSpaces, cs)
+	if pw.iccColorSpaces == nil {
+		pw.iccColorSpaces = make(map[Objectnumber]*ColorSpace)
+	}
+	pw.iccColorSpaces[num] = cs
+	return cs, nil
+}
@@ -0,0 +1,186 @@
+package pdf
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestCIEColorSpace(t *testing.T) {
+	pw, buf := newTestPDF()
+	d65 := [3]float64{0.9505, 1, 1.089}
+	gray, err := pw.NewCIEColorSpace(CIEColorSpace{Family: "CalGray", WhitePoint: d65, Gamma: []float64{2.2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rgb, err := pw.NewCIEColorSpace(CIEColorSpace{
+		Family:     "CalRGB",
+		WhitePoint: d65,
+		BlackPoint: [3]float64{0.01, 0.01, 0.01},
+		Gamma:      []float64{1.8, 1.8, 1.8},
+		Matrix:     []float64{0.4497, 0.2446, 0.0252, 0.3163, 0.672, 0.1412, 0.1845, 0.0833, 0.9227},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lab, err := pw.NewCIEColorSpace(CIEColorSpace{Family: "Lab", WhitePoint: d65, Range: []float64{-128, 127, -128, 127}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gray.Components() != 1 || rgb.Components() != 3 || lab.Components() != 3 {
+		t.Errorf("components = %d %d %d, want 1 3 3", gray.Components(), rgb.Components(), lab.Components())
+	}
+	sh, err := pw.WriteShading(Shading{
+		ShadingType: 2,
+		ColorSpace:  lab.Ref(),
+		Coords:      []float64{0, 0, 100, 0},
+		Function:    Function{FunctionType: 2, C0: []float64{50, -20, 0}, C1: []float64{80, 20, 60}, N: 1},
+	})
+	if err != nil {
+		t.Fatalf("WriteShading: %v", err)
+	}
+	content := pw.NewObject()
+	content.Data.WriteString("/cs1 cs 0.5 0.2 0.1 sc 0 0 10 10 re f")
+	page := pw.AddPage(content, 0)
+	page.ColorSpaces = map[Name]Name{"cs1": rgb.Ref(), "cs2": gray.Ref()}
+	page.Shadings = map[Name]*Object{"sh1": sh}
+	if err := pw.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"[ /CalGray <<",
+		"/Gamma 2.2",
+		"/WhitePoint [0.9505 1 1.089]",
+		"[ /CalRGB <<",
+		"/BlackPoint [0.01 0.01 0.01]",
+		"/Gamma [1.8 1.8 1.8]",
+		"/Matrix [0.4497 0.2446 0.0252 0.3163 0.672 0.1412 0.1845 0.0833 0.9227]",
+		"[ /Lab <<",
+		"/Range [-128 127 -128 127]",
+		fmt.Sprintf("/ColorSpace %s", lab.Objnum.Ref()),
+		fmt.Sprintf("/cs1 %s", rgb.Objnum.Ref()),
+		fmt.Sprintf("/cs2 %s", gray.Objnum.Ref()),
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q", want)
+		}
+	}
+}
+
+func TestICCBasedColorSpace(t *testing.T) {
+	var rgb, again, cmyk *ColorSpace
+	out, err := writePDFATestPDF(t, &PDFA{Level: PDFA2b, ICCProfile: testICCProfile("RGB ")}, func(pw *PDF) {
+		var err error
+		if rgb, err = pw.NewICCBasedColorSpace(testICCProfile("RGB "), ""); err != nil {
+			t.Fatal(err)
+		}
+		if again, err = pw.NewICCBasedColorSpace(testICCProfile("RGB "), "/DeviceRGB"); err != nil {
+			t.Fatal(err)
+		}
+		if cmyk, err = pw.NewICCBasedColorSpace(testICCProfile("CMYK"), ""); err != nil {
+			t.Fatal(err)
+		}
+		content := pw.NewObject()
+		page := pw.AddPage(content, 0)
+		page.ColorSpaces = map[Name]Name{"cs1": rgb.Ref(), "cs2": cmyk.Ref()}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rgb != again {
+		t.Error("identical profiles return different colour spaces")
+	}
+	if rgb.Components() != 3 || cmyk.Components() != 4 {
+		t.Errorf("components = %d %d, want 3 4", rgb.Components(), cmyk.Components())
+	}
+	if n := strings.Count(out, "/Alternate /DeviceRGB"); n != 1 {
+		t.Errorf("RGB profile embedded %d times, want 1", n)
+	}
+	for _, want := range []string{
+		"/Alternate /DeviceCMYK",
+		"/N 4",
+		"[ /ICCBased ",
+		fmt.Sprintf("/cs1 %s", rgb.Objnum.Ref()),
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q", want)
+		}
+	}
+	// the output intent shares the profile stream of the colour space
+	i := strings.Index(out, "/DestOutputProfile ")
+	ref := strings.Fields(out[i:])[1]
+	if !strings.Contains(out, "[ /ICCBased "+ref+" 0 R ]") {
+		t.Errorf("output intent profile %s is not the profile of the colour space", ref)
+	}
+}
+
+func TestImageColorSpace(t *testing.T) {
+	pw, buf := newTestPDF()
+	img, err := pw.LoadImageFile(writeTempPNG(t, t.TempDir(), 4, 4, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gray, err := pw.NewICCBasedColorSpace(testICCProfile("GRAY"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := img.SetColorSpace(gray); err == nil {
+		t.Error("expected an error for a gray colour space on an RGB image")
+	}
+	rgb, err := pw.NewICCBasedColorSpace(testICCProfile("RGB "), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := img.SetColorSpace(rgb); err != nil {
+		t.Fatal(err)
+	}
+	page := pw.AddPage(pw.NewObject(), 0)
+	page.Images = append(page.Images, img)
+	if err := pw.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if want := fmt.Sprintf("/ColorSpace %s", rgb.Objnum.Ref()); !strings.Contains(buf.String(), want) {
+		t.Errorf("output does not contain %q", want)
+	}
+}
+
+func TestColorSpaceErrors(t *testing.T) {
+	d50 := [3]float64{0.9642, 1, 0.8249}
+	for _, c := range []CIEColorSpace{
+		{Family: "CalCMYK", WhitePoint: d50},
+		{Family: "CalGray"},
+		{Family: "CalGray", WhitePoint: [3]float64{0.9642, 0.9, 0.8249}},
+		{Family: "CalGray", WhitePoint: d50, BlackPoint: [3]float64{-1, 0, 0}},
+		{Family: "CalGray", WhitePoint: d50, Gamma: []float64{1, 1, 1}},
+		{Family: "CalRGB", WhitePoint: d50, Gamma: []float64{1, 0, 1}},
+		{Family: "CalRGB", WhitePoint: d50, Matrix: []float64{1, 0, 0}},
+		{Family: "Lab", WhitePoint: d50, Matrix: []float64{1, 0, 0, 0, 1, 0, 0, 0, 1}},
+		{Family: "Lab", WhitePoint: d50, Range: []float64{100, -100, -100, 100}},
+		{Family: "CalRGB", WhitePoint: d50, Range: []float64{-100, 100, -100, 100}},
+	} {
+		pw, _ := newTestPDF()
+		if _, err := pw.NewCIEColorSpace(c); err == nil {
+			t.Errorf("expected an error for %+v", c)
+		}
+	}
+	pw, _ := newTestPDF()
+	if _, err := pw.NewICCBasedColorSpace([]byte("not a profile"), ""); err == nil {
+		t.Error("expected an error for an invalid profile")
+	}
+	if _, err := pw.NewICCBasedColorSpace(testICCProfile("RGB "), "/DeviceCMYK"); err == nil {
+		t.Error("expected an error for an alternate colour space with 4 components")
+	}
+	lab, err := pw.NewCIEColorSpace(CIEColorSpace{Family: "Lab", WhitePoint: d50})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pw.WriteShading(Shading{
+		ShadingType: 2,
+		ColorSpace:  lab.Ref(),
+		Coords:      []float64{0, 0, 1, 0},
+		Function:    Function{FunctionType: 2, C0: []float64{0}, C1: []float64{1}, N: 1},
+	}); err == nil {
+		t.Error("expected an error for a gray function in a Lab shading")
+	}
+}
@@ -0,0 +1,172 @@
+package pdf
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// FitType determines how a destination shows the target page.
+type FitType int
+
+const (
+	// FitXYZ puts Left and Top at the upper left corner of the window and
+	// magnifies the page by Zoom.
+	FitXYZ FitType = iota
+	// Fit fits the whole page into the window.
+	Fit
+	// FitH fits the width of the page into the window, Top is at the top
+	// of the window.
+	FitH
+	// FitV fits the height of the page into the window, Left is at the left
+	// edge of the window.
+	FitV
+	// FitR fits the rectangle Left, Bottom, Right, Top into the window.
+	FitR
+	// FitB fits the bounding box of the page contents into the window.
+	FitB
+	// FitBH fits the width of the bounding box into the window, Top is at
+	// the top of the window.
+	FitBH
+	// FitBV fits the height of the bounding box into the window, Left is at
+	// the left edge of the window.
+	FitBV
+)
+
+var fitTypeNames = map[FitType]string{
+	FitXYZ: "/XYZ",
+	Fit:    "/Fit",
+	FitH:   "/FitH",
+	FitV:   "/FitV",
+	FitR:   "/FitR",
+	FitB:   "/FitB",
+	FitBH:  "/FitBH",
+	FitBV:  "/FitBV",
+}
+
+// Destination is a view of a page in this document, in another PDF file
+// (File) or in a PDF file embedded in this document (Embedded). Coordinates
+// are in PDF units with the origin in the lower left corner of the page.
+type Destination struct {
+	// Page is the target page in this document.
+	Page Objectnumber
+	// PageIndex is the target page (starting at 0) in a remote or embedded
+	// document.
+	PageIndex int
+	// Name is a named destination in a remote or embedded document. It
+	// takes precedence over PageIndex and the fit parameters.
+	Name string
+	Fit  FitType
+	// Left, Bottom, Right and Top are the coordinates the fit type
+	// requires. FitXYZ uses Left and Top, FitH and FitBH use Top, FitV and
+	// FitBV use Left and FitR uses all four.
+	Left, Bottom, Right, Top float64
+	// Zoom is the magnification of a FitXYZ destination, 1 is 100%. Zero
+	// keeps the current magnification.
+	Zoom float64
+	// File is the PDF file of a remote destination (GoToR).
+	File string
+	// Embedded is the target of a GoToE action, a PDF file attached to this
+	// document with PDF.AttachFile.
+	Embedded *Attachment
+	// NewWindow opens a remote or embedded document in a new window.
+	NewWindow bool
+}
+
+// remote reports whether the destination is in another document and must
+// be written as an action.
+func (d *Destination) remote() bool {
+	return d.File != "" || d.Embedded != nil
+}
+
+// checkDestination validates the fit type and the coordinates it requires.
+func (pw *PDF) checkDestination(d *Destination) error {
+	if _, ok := fitTypeNames[d.Fit]; !ok {
+		return fmt.Errorf("pdf: unknown destination fit type %d", d.Fit)
+	}
+	for _, f := range []float64{d.Left, d.Bottom, d.Right, d.Top, d.Zoom} {
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return fmt.Errorf("pdf: destination coordinate %g is not a finite number", f)
+		}
+	}
+	if d.Zoom < 0 {
+		return fmt.Errorf("pdf: destination zoom %g must not be negative", d.Zoom)
+	}
+	if d.Fit == FitR && (d.Right <= d.Left || d.Top <= d.Bottom) {
+		return fmt.Errorf("pdf: FitR destination needs a rectangle with left < right and bottom < top, got [%g %g %g %g]", d.Left, d.Bottom, d.Right, d.Top)
+	}
+	switch {
+	case d.File != "" && d.Embedded != nil:
+		return fmt.Errorf("pdf: destination has both a file (%s) and an embedded file (%s)", d.File, d.Embedded.Name)
+	case d.remote():
+		if d.Page != 0 {
+			return fmt.Errorf("pdf: destination in another document must use PageIndex instead of Page")
+		}
+		if d.Name == "" && d.PageIndex < 0 {
+			return fmt.Errorf("pdf: destination page index %d must not be negative", d.PageIndex)
+		}
+		if d.Embedded != nil && !slices.Contains(pw.attachments, d.Embedded) {
+			return fmt.Errorf("pdf: embedded destination file %q is not attached to the document", d.Embedded.Name)
+		}
+	case d.Page == 0:
+		return fmt.Errorf("pdf: destination without a page")
+	}
+	return nil
+}
+
+// explicit returns the destination array. The page is a page reference in
+// this document and a page index for remote documents.
+func (d *Destination) explicit() string {
+	var page string
+	if d.remote() {
+		page = strconv.Itoa(d.PageIndex)
+	} else {
+		page = d.Page.Ref()
+	}
+	arr := []string{page, fitTypeNames[d.Fit]}
+	switch d.Fit {
+	case FitXYZ:
+		zoom := "null"
+		if d.Zoom != 0 {
+			zoom = fmtPDFFloat(d.Zoom)
+		}
+		arr = append(arr, fmtPDFFloat(d.Left), fmtPDFFloat(d.Top), zoom)
+	case FitH, FitBH:
+		arr = append(arr, fmtPDFFloat(d.Top))
+	case FitV, FitBV:
+		arr = append(arr, fmtPDFFloat(d.Left))
+	case FitR:
+		arr = append(arr, fmtPDFFloat(d.Left), fmtPDFFloat(d.Bottom), fmtPDFFloat(d.Right), fmtPDFFloat(d.Top))
+	}
+	return "[" + strings.Join(arr, " ") + "]"
+}
+
+// target returns the /D entry: the destination name for named destinations
+// in other documents or the destination array.
+func (d *Destination) target() string {
+	if d.remote() && d.Name != "" {
+		return stringToPDF(d.Name)
+	}
+	return d.explicit()
+}
+
+// writeDestObj writes a destination dictionary for the /Dests name tree.
+func (pw *PDF) writeDestObj(d *Destination) (Objectnumber, error) {
+	if err := pw.checkDestination(d); err != nil {
+		return 0, err
+	}
+	if d.remote() {
+		return 0, fmt.Errorf("pdf: a named destination cannot point to another document")
+	}
+	obj := pw.NewObject()
+	obj.Dict(Dict{
+		"D": d.explicit(),
+	})
+
+	if err := obj.Save(); err != nil {
+		return 0, err
+	}
+	return obj.ObjectNumber, nil
+}
@@ -0,0 +1,123 @@
+package pdf
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestDestinationFitTypes(t *testing.T) {
+	for _, tc := range []struct {
+		d    Destination
+		want string
+	}{
+		{Destination{Page: 5, Left: 10, Top: 700}, "[5 0 R /XYZ 10 700 null]"},
+		{Destination{Page: 5, Left: 10, Top: 700, Zoom: 1.5}, "[5 0 R /XYZ 10 700 1.5]"},
+		{Destination{Page: 5, Fit: Fit}, "[5 0 R /Fit]"},
+		{Destination{Page: 5, Fit: FitH, Top: 500}, "[5 0 R /FitH 500]"},
+		{Destination{Page: 5, Fit: FitV, Left: 72}, "[5 0 R /FitV 72]"},
+		{Destination{Page: 5, Fit: FitR, Left: 10, Bottom: 20, Right: 300, Top: 400.5}, "[5 0 R /FitR 10 20 300 400.5]"},
+		{Destination{Page: 5, Fit: FitB}, "[5 0 R /FitB]"},
+		{Destination{Page: 5, Fit: FitBH, Top: 12}, "[5 0 R /FitBH 12]"},
+		{Destination{Page: 5, Fit: FitBV, Left: 12}, "[5 0 R /FitBV 12]"},
+		{Destination{File: "other.pdf", PageIndex: 2, Fit: Fit}, "[2 /Fit]"},
+	} {
+		pw, _ := newTestPDF()
+		if err := pw.checkDestination(&tc.d); err != nil {
+			t.Errorf("%v: %s", tc.d, err)
+			continue
+		}
+		if got := tc.d.explicit(); got != tc.want {
+			t.Errorf("explicit() = %q, want %q", got, tc.want)
+		}
+	}
+}
+
+func TestDestinationErrors(t *testing.T) {
+	pw, _ := newTestPDF()
+	for _, d := range []Destination{
+		{Fit: Fit},
+		{Page: 1, Fit: FitType(99)},
+		{Page: 1, Fit: FitR, Left: 10, Bottom: 10, Right: 10, Top: 20},
+		{Page: 1, Fit: FitR},
+		{Page: 1, Zoom: -1},
+		{Page: 1, Fit: FitH, Top: math.NaN()},
+		{File: "a.pdf", Page: 1},
+		{File: "a.pdf", PageIndex: -1},
+		{File: "a.pdf", Embedded: &Attachment{Name: "b.pdf"}},
+		{Embedded: &Attachment{Name: "b.pdf"}},
+	} {
+		if err := pw.checkDestination(&d); err == nil {
+			t.Errorf("expected an error for %v", d)
+		}
+	}
+}
+
+func TestNameDestFit(t *testing.T) {
+	pw, buf := newTestPDF()
+	page := pw.AddPage(pw.NewObject(), 0)
+	pw.NameDestinations["old"] = &NameDest{Name: "old", PageObjectnumber: page.Objnum, X: 10, Y: 20}
+	pw.NameDestinations["fit"] = &NameDest{Name: "fit", Destination: &Destination{Page: page.Objnum, Fit: FitBH, Top: 300}}
+	if err := pw.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		fmt.Sprintf("/D [%s /XYZ 10 20 null]", page.Objnum.Ref()),
+		fmt.Sprintf("/D [%s /FitBH 300]", page.Objnum.Ref()),
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q", want)
+		}
+	}
+
+	pw, _ = newTestPDF()
+	pw.AddPage(pw.NewObject(), 0)
+	pw.NameDestinations["remote"] = &NameDest{Name: "remote", Destination: &Destination{File: "other.pdf"}}
+	if err := pw.Finish(); err == nil {
+		t.Error("expected an error for a remote named destination")
+	}
+}
+
+func TestOutlineDestinations(t *testing.T) {
+	pw, buf := newTestPDF()
+	page := pw.AddPage(pw.NewObject(), 0)
+	manual := &Attachment{Name: "manual.pdf", MIMEType: "application/pdf", Data: []byte("%PDF-1.7")}
+	if err := pw.AttachFile(manual); err != nil {
+		t.Fatal(err)
+	}
+	pw.Outlines = []*Outline{
+		{Title: "Local", Destination: &Destination{Page: page.Objnum, Fit: FitR, Left: 0, Bottom: 0, Right: 100, Top: 100}},
+		{Title: "Remote page", Destination: &Destination{File: "other.pdf", PageIndex: 3, Fit: FitH, Top: 800, NewWindow: true}},
+		{Title: "Remote name", Destination: &Destination{File: "other.pdf", Name: "chapter2"}},
+		{Title: "Embedded", Destination: &Destination{Embedded: manual, Fit: Fit}},
+	}
+	if err := pw.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		fmt.Sprintf("/Dest [%s /FitR 0 0 100 100]", page.Objnum.Ref()),
+		"/S /GoToR",
+		"/D [3 /FitH 800]",
+		"/NewWindow true",
+		"/F (other.pdf)",
+		"/D (chapter2)",
+		"/S /GoToE",
+		"/N (manual.pdf)",
+		"/R /C",
+		"/D [0 /Fit]",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q", want)
+		}
+	}
+
+	pw, _ = newTestPDF()
+	pw.AddPage(pw.NewObject(), 0)
+	pw.Outlines = []*Outline{{Title: "Broken", Destination: &Destination{Fit: FitR}}}
+	if err := pw.Finish(); err == nil {
+		t.Error("expected an error for an invalid outline destination")
+	}
+}
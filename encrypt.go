@@ -0,0 +1,612 @@
+package pdf
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/rc4"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// EncryptionAlgorithm selects the cipher of the standard security handler.
+type EncryptionAlgorithm int
+
+const (
+	// AES256 is the standard security handler revision 6 (PDF 2.0, also
+	// understood by all current PDF 1.7 readers).
+	AES256 EncryptionAlgorithm = iota
+	// AES128 is the standard security handler revision 4 with the AESV2
+	// crypt filter, for readers that predate AES-256.
+	AES128
+)
+
+// Permission is a set of user access permissions (PDF 1.7 §7.6.3.2, Table 22).
+// The owner password always grants full access.
+type Permission uint32
+
+// Permission flags. The values are the bit positions defined by the PDF
+// specification, so they can be or-ed together.
+const (
+	PermissionPrint                Permission = 1 << 2  // print the document
+	PermissionModify               Permission = 1 << 3  // modify the contents
+	PermissionCopy                 Permission = 1 << 4  // copy or extract text and graphics
+	PermissionAnnotate             Permission = 1 << 5  // add or modify annotations, fill in forms
+	PermissionFillForms            Permission = 1 << 8  // fill in existing form fields
+	PermissionExtractAccessibility Permission = 1 << 9  // extract text for accessibility
+	PermissionAssemble             Permission = 1 << 10 // insert, rotate or delete pages
+	PermissionPrintHighQuality     Permission = 1 << 11 // print in full quality
+
+	PermissionAll = PermissionPrint | PermissionModify | PermissionCopy | PermissionAnnotate |
+		PermissionFillForms | PermissionExtractAccessibility | PermissionAssemble | PermissionPrintHighQuality
+)
+
+// Encryption holds the settings for the standard security handler. Pass it
+// to PDF.SetEncryption before anything is written to the PDF.
+type Encryption struct {
+	Algorithm     EncryptionAlgorithm
+	UserPassword  string // password to open the document, may be empty
+	OwnerPassword string // password for full access; defaults to a random password
+	Permissions   Permission
+}
+
+// securityHandler contains the key material derived from an Encryption.
+type securityHandler struct {
+	revision int
+	fileKey  []byte
+	dict     Dict
+}
+
+var passwordPadding = []byte{
+	0x28, 0xBF, 0x4E, 0x5E, 0x4E, 0x75, 0x8A, 0x41, 0x64, 0x00, 0x4E, 0x56, 0xFF, 0xFA, 0x01, 0x08,
+	0x2E, 0x2E, 0x00, 0xB6, 0xD0, 0x68, 0x3E, 0x80, 0x2F, 0x0C, 0xA9, 0xFE, 0x64, 0x53, 0x69, 0x7A,
+}
+
+// SetEncryption enables the standard security handler for the document. It
+// must be called before the first object is written because every string and
+// stream is encrypted as it is saved. The file identifier (/ID) is fixed
+// here, since the AES-128 key depends on it.
+func (pw *PDF) SetEncryption(enc *Encryption) error {
+	if pw.pos > 0 {
+		return errors.New("pdf: encryption must be set before the first object is written")
+	}
+	if enc == nil {
+		pw.security = nil
+		return nil
+	}
+	id := md5.New()
+	fmt.Fprint(id, time.Now().UnixNano(), enc.UserPassword, enc.OwnerPassword)
+	salt := make([]byte, 16)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return err
+	}
+	id.Write(salt)
+	pw.fileID = id.Sum(nil)
+
+	var err error
+	switch enc.Algorithm {
+	case AES256:
+		pw.security, err = newSecurityHandlerR6(enc)
+	case AES128:
+		pw.security, err = newSecurityHandlerR4(enc, pw.fileID)
+	default:
+		err = fmt.Errorf("pdf: unknown encryption algorithm %d", enc.Algorithm)
+	}
+	return err
+}
+
+// permissionValue returns the /P entry: the permission bits plus the bits
+// that must be set for revision 3 and later.
+func permissionValue(p Permission) int32 {
+	return int32(uint32(p&PermissionAll) | 0xFFFFF0C0)
+}
+
+func newSecurityHandlerR6(enc *Encryption) (*securityHandler, error) {
+	random := make([]byte, 32+4*8+4)
+	if _, err := io.ReadFull(rand.Reader, random); err != nil {
+		return nil, err
+	}
+	fileKey := random[:32]
+	userValidationSalt, userKeySalt := random[32:40], random[40:48]
+	ownerValidationSalt, ownerKeySalt := random[48:56], random[56:64]
+
+	userPW := truncatePassword(enc.UserPassword)
+	ownerPW := truncatePassword(enc.OwnerPassword)
+	if len(ownerPW) == 0 {
+		ownerPW = []byte(hex.EncodeToString(random[64:]))
+	}
+
+	u := append(hashR6(userPW, userValidationSalt, nil), userValidationSalt...)
+	u = append(u, userKeySalt...)
+	ue, err := aesCBCNoPadding(hashR6(userPW, userKeySalt, nil), fileKey)
+	if err != nil {
+		return nil, err
+	}
+	o := append(hashR6(ownerPW, ownerValidationSalt, u), ownerValidationSalt...)
+	o = append(o, ownerKeySalt...)
+	oe, err := aesCBCNoPadding(hashR6(ownerPW, ownerKeySalt, u), fileKey)
+	if err != nil {
+		return nil, err
+	}
+
+	p := permissionValue(enc.Permissions)
+	perms := make([]byte, 16)
+	binary.LittleEndian.PutUint32(perms, uint32(p))
+	copy(perms[4:], []byte{0xff, 0xff, 0xff, 0xff, 'T', 'a', 'd', 'b'})
+	copy(perms[12:], random[64:68])
+	block, err := aes.NewCipher(fileKey)
+	if err != nil {
+		return nil, err
+	}
+	block.Encrypt(perms, perms)
+
+	return &securityHandler{
+		revision: 6,
+		fileKey:  fileKey,
+		dict: Dict{
+			"Filter": "/Standard",
+			"V":      "5",
+			"R":      "6",
+			"Length": "256",
+			"CF":     "<< /StdCF << /AuthEvent /DocOpen /CFM /AESV3 /Length 32 >> >>",
+			"StmF":   "/StdCF",
+			"StrF":   "/StdCF",
+			"O":      hexString(o),
+			"U":      hexString(u),
+			"OE":     hexString(oe),
+			"UE":     hexString(ue),
+			"Perms":  hexString(perms),
+			"P":      strconv.Itoa(int(p)),
+		},
+	}, nil
+}
+
+func newSecurityHandlerR4(enc *Encryption, fileID []byte) (*securityHandler, error) {
+	userPW := padPassword(enc.UserPassword)
+	ownerPW := padPassword(enc.OwnerPassword)
+	if enc.OwnerPassword == "" {
+		random := make([]byte, 16)
+		if _, err := io.ReadFull(rand.Reader, random); err != nil {
+			return nil, err
+		}
+		ownerPW = padPassword(hex.EncodeToString(random))
+	}
+
+	// Algorithm 3: the owner entry
+	sum := md5.Sum(ownerPW)
+	for range 50 {
+		sum = md5.Sum(sum[:])
+	}
+	o := rc4Rounds(sum[:16], userPW)
+
+	// Algorithm 2: the file encryption key
+	p := permissionValue(enc.Permissions)
+	h := md5.New()
+	h.Write(userPW)
+	h.Write(o)
+	binary.Write(h, binary.LittleEndian, p)
+	h.Write(fileID)
+	fileKey := h.Sum(nil)
+	for range 50 {
+		s := md5.Sum(fileKey[:16])
+		fileKey = s[:]
+	}
+	fileKey = fileKey[:16]
+
+	// Algorithm 5: the user entry
+	h = md5.New()
+	h.Write(passwordPadding)
+	h.Write(fileID)
+	u := append(rc4Rounds(fileKey, h.Sum(nil)), make([]byte, 16)...)
+
+	return &securityHandler{
+		revision: 4,
+		fileKey:  fileKey,
+		dict: Dict{
+			"Filter": "/Standard",
+			"V":      "4",
+			"R":      "4",
+			"Length": "128",
+			"CF":     "<< /StdCF << /AuthEvent /DocOpen /CFM /AESV2 /Length 16 >> >>",
+			"StmF":   "/StdCF",
+			"StrF":   "/StdCF",
+			"O":      hexString(o),
+			"U":      hexString(u),
+			"P":      strconv.Itoa(int(p)),
+		},
+	}, nil
+}
+
+// truncatePassword returns the UTF-8 password limited to 127 bytes as
+// required for revision 6.
+func truncatePassword(pw string) []byte {
+	b := []byte(pw)
+	if len(b) > 127 {
+		b = b[:127]
+	}
+	return b
+}
+
+// padPassword pads or truncates the password to 32 bytes (Algorithm 2 step a).
+func padPassword(pw string) []byte {
+	b := []byte(pw)
+	if len(b) > 32 {
+		b = b[:32]
+	}
+	return append(b, passwordPadding[:32-len(b)]...)
+}
+
+// rc4Rounds encrypts data with key and then 19 more times with key xor i,
+// as used by algorithms 3 and 5.
+func rc4Rounds(key, data []byte) []byte {
+	out := bytes.Clone(data)
+	k := make([]byte, len(key))
+	for i := range 20 {
+		for j := range key {
+			k[j] = key[j] ^ byte(i)
+		}
+		c, _ := rc4.NewCipher(k)
+		c.XORKeyStream(out, out)
+	}
+	return out
+}
+
+// hashR6 is algorithm 2.B of ISO 32000-2: the hash for revision 6 passwords.
+// udata is the 48 byte /U entry for owner passwords and nil otherwise.
+func hashR6(password, salt, udata []byte) []byte {
+	h := sha256.New()
+	h.Write(password)
+	h.Write(salt)
+	h.Write(udata)
+	k := h.Sum(nil)
+	for round := 0; ; {
+		seq := make([]byte, 0, len(password)+len(k)+len(udata))
+		seq = append(seq, password...)
+		seq = append(seq, k...)
+		seq = append(seq, udata...)
+		k1 := bytes.Repeat(seq, 64)
+		block, _ := aes.NewCipher(k[:16])
+		e := make([]byte, len(k1))
+		cipher.NewCBCEncrypter(block, k[16:32]).CryptBlocks(e, k1)
+		var sum int
+		for _, b := range e[:16] {
+			sum += int(b)
+		}
+		var next hash.Hash
+		switch sum % 3 {
+		case 0:
+			next = sha256.New()
+		case 1:
+			next = sha512.New384()
+		default:
+			next = sha512.New()
+		}
+		next.Write(e)
+		k = next.Sum(nil)
+		round++
+		if round >= 64 && int(e[len(e)-1]) <= round-32 {
+			break
+		}
+	}
+	return k[:32]
+}
+
+// aesCBCNoPadding encrypts data (a multiple of 16 bytes) with a zero IV.
+func aesCBCNoPadding(key, data []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, len(data))
+	cipher.NewCBCEncrypter(block, make([]byte, aes.BlockSize)).CryptBlocks(out, data)
+	return out, nil
+}
+
+// objectKey returns the key used for strings and streams of the object onum.
+func (sh *securityHandler) objectKey(onum Objectnumber) []byte {
+	if sh.revision == 6 {
+		return sh.fileKey
+	}
+	h := md5.New()
+	h.Write(sh.fileKey)
+	h.Write([]byte{byte(onum), byte(onum >> 8), byte(onum >> 16), 0, 0})
+	h.Write([]byte("sAlT"))
+	return h.Sum(nil)
+}
+
+// encrypt returns data encrypted with AES-CBC: a random initialization
+// vector followed by the PKCS#5 padded cipher text.
+func (sh *securityHandler) encrypt(onum Objectnumber, data []byte) ([]byte, error) {
+	block, err := aes.NewCipher(sh.objectKey(onum))
+	if err != nil {
+		return nil, err
+	}
+	pad := aes.BlockSize - len(data)%aes.BlockSize
+	out := make([]byte, aes.BlockSize+len(data)+pad)
+	if _, err := io.ReadFull(rand.Reader, out[:aes.BlockSize]); err != nil {
+		return nil, err
+	}
+	plain := out[aes.BlockSize:]
+	copy(plain, data)
+	for i := len(data); i < len(plain); i++ {
+		plain[i] = byte(pad)
+	}
+	cipher.NewCBCEncrypter(block, out[:aes.BlockSize]).CryptBlocks(plain, plain)
+	return out, nil
+}
+
+// encryptStrings returns the PDF object text s with every literal and
+// hexadecimal string replaced by its encrypted hexadecimal form. Names,
+// numbers and other tokens are copied unchanged.
+func (sh *securityHandler) encryptStrings(onum Objectnumber, s []byte) ([]byte, error) {
+	var out bytes.Buffer
+	out.Grow(len(s))
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '%':
+			end := bytes.IndexAny(s[i:], "\r\n")
+			if end < 0 {
+				end = len(s) - i
+			}
+			out.Write(s[i : i+end])
+			i += end
+		case c == '(':
+			str, n := parseLiteralString(s[i:])
+			enc, err := sh.encrypt(onum, str)
+			if err != nil {
+				return nil, err
+			}
+			out.WriteString(hexString(enc))
+			i += n
+		case c == '<' && i+1 < len(s) && s[i+1] == '<':
+			out.WriteString("<<")
+			i += 2
+		case c == '<':
+			end := bytes.IndexByte(s[i:], '>')
+			if end < 0 {
+				end = len(s) - i - 1
+			}
+			enc, err := sh.encrypt(onum, decodeHexString(s[i+1:i+end]))
+			if err != nil {
+				return nil, err
+			}
+			out.WriteString(hexString(enc))
+			i += end + 1
+		case c == '/':
+			// a name may contain any regular character, so copy it as a whole
+			j := i + 1
+			for j < len(s) && !bytes.ContainsRune([]byte(" \t\r\n\f\x00()<>[]{}/%"), rune(s[j])) {
+				j++
+			}
+			out.Write(s[i:j])
+			i = j
+		default:
+			out.WriteByte(c)
+			i++
+		}
+	}
+	return out.Bytes(), nil
+}
+
+// parseLiteralString decodes the literal string at the start of s (which
+// must begin with an opening parenthesis) and returns the string bytes and
+// the number of bytes consumed.
+func parseLiteralString(s []byte) ([]byte, int) {
+	var str []byte
+	depth := 0
+	i := 0
+	for ; i < len(s); i++ {
+		c := s[i]
+		switch c {
+		case '(':
+			depth++
+			if depth == 1 {
+				continue
+			}
+		case ')':
+			depth--
+			if depth == 0 {
+				return str, i + 1
+			}
+		case '\\':
+			i++
+			if i >= len(s) {
+				return str, i
+			}
+			switch e := s[i]; e {
+			case 'n':
+				str = append(str, '\n')
+			case 'r':
+				str = append(str, '\r')
+			case 't':
+				str = append(str, '\t')
+			case 'b':
+				str = append(str, '\b')
+			case 'f':
+				str = append(str, '\f')
+			case '\r':
+				if i+1 < len(s) && s[i+1] == '\n' {
+					i++
+				}
+			case '\n':
+			case '0', '1', '2', '3', '4', '5', '6', '7':
+				v := 0
+				j := 0
+				for ; j < 3 && i+j < len(s) && s[i+j] >= '0' && s[i+j] <= '7'; j++ {
+					v = v*8 + int(s[i+j]-'0')
+				}
+				i += j - 1
+				str = append(str, byte(v))
+			default:
+				str = append(str, e)
+			}
+			continue
+		}
+		str = append(str, c)
+	}
+	return str, i
+}
+
+// decodeHexString decodes the contents of a hexadecimal string, ignoring
+// white space. A missing final digit is assumed to be 0.
+func decodeHexString(s []byte) []byte {
+	digits := make([]byte, 0, len(s)+1)
+	for _, c := range s {
+		switch {
+		case c >= '0' && c <= '9', c >= 'a' && c <= 'f', c >= 'A' && c <= 'F':
+			digits = append(digits, c)
+		}
+	}
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	out := make([]byte, len(digits)/2)
+	hex.Decode(out, digits)
+	return out
+}
+
+// hexString returns b as a PDF hexadecimal string <...>.
+func hexString(b []byte) string {
+	return "<" + hex.EncodeToString(b) + ">"
+}
+
+// rawStreamLength matches a direct or an indirect /Length entry.
+var rawStreamLength = regexp.MustCompile(`/Length\s+\d+(?:\s+\d+\s+R\b)?`)
+
+// rawStreamKeyword returns the position of the stream keyword which follows
+// the dictionary at the start of data or -1 if data is not a stream. Names,
+// strings and comments inside the dictionary are skipped so that for example
+// /Upstream is not taken for the keyword.
+func rawStreamKeyword(data []byte) int {
+	isSpace := func(c byte) bool {
+		return c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '\f' || c == 0
+	}
+	i := 0
+	for i < len(data) && isSpace(data[i]) {
+		i++
+	}
+	if !bytes.HasPrefix(data[i:], []byte("<<")) {
+		return -1
+	}
+	depth := 0
+	for i < len(data) {
+		switch c := data[i]; {
+		case c == '<' && i+1 < len(data) && data[i+1] == '<':
+			depth++
+			i += 2
+		case c == '>' && i+1 < len(data) && data[i+1] == '>':
+			depth--
+			i += 2
+			if depth == 0 {
+				for i < len(data) && isSpace(data[i]) {
+					i++
+				}
+				if bytes.HasPrefix(data[i:], []byte("stream")) {
+					return i
+				}
+				return -1
+			}
+		case c == '<':
+			// hexadecimal string
+			if end := bytes.IndexByte(data[i:], '>'); end >= 0 {
+				i += end + 1
+			} else {
+				return -1
+			}
+		case c == '(':
+			// literal string with balanced parentheses and escapes
+			nesting := 0
+			for ; i < len(data); i++ {
+				if data[i] == '\\' {
+					i++
+				} else if data[i] == '(' {
+					nesting++
+				} else if data[i] == ')' {
+					if nesting--; nesting == 0 {
+						break
+					}
+				}
+			}
+			i++
+		case c == '%':
+			for i < len(data) && data[i] != '\r' && data[i] != '\n' {
+				i++
+			}
+		default:
+			i++
+		}
+	}
+	return -1
+}
+
+// encryptRaw encrypts a raw object (everything between "obj" and
+// "endobj"), such as the objects imported by gofpdi. For streams the
+// /Length entry is set to the size of the encrypted data, an indirect length
+// is replaced by the direct value.
+func (sh *securityHandler) encryptRaw(onum Objectnumber, data []byte) ([]byte, error) {
+	head, body := data, []byte(nil)
+	trimmed := bytes.TrimRight(data, " \r\n")
+	if bytes.HasSuffix(trimmed, []byte("endstream")) {
+		if si := rawStreamKeyword(data); si >= 0 {
+			// the stream keyword is followed by CRLF or LF
+			start := si + len("stream")
+			if start < len(data) && data[start] == '\r' {
+				start++
+			}
+			start++
+			end := len(trimmed) - len("endstream")
+			if end > start && data[end-1] == '\n' {
+				end--
+				if end > start && data[end-1] == '\r' {
+					end--
+				}
+			}
+			head, body = data[:si], data[start:end]
+		}
+	}
+	encHead, err := sh.encryptStrings(onum, head)
+	if err != nil {
+		return nil, err
+	}
+	if body == nil {
+		return encHead, nil
+	}
+	encBody, err := sh.encrypt(onum, body)
+	if err != nil {
+		return nil, err
+	}
+	if loc := rawStreamLength.FindAllIndex(encHead, -1); len(loc) > 0 {
+		last := loc[len(loc)-1]
+		encHead = append(encHead[:last[0]:last[0]], append([]byte("/Length "+strconv.Itoa(len(encBody))), encHead[last[1]:]...)...)
+	}
+	var out bytes.Buffer
+	out.Write(bytes.TrimRight(encHead, " \r\n"))
+	out.WriteString("\nstream\n")
+	out.Write(encBody)
+	out.WriteString("\nendstream")
+	return out.Bytes(), nil
+}
+
+// writeEncryptDict writes the /Encrypt dictionary. It is neither encrypted
+// nor placed in an object stream.
+func (pw *PDF) writeEncryptDict() (*Object, error) {
+	obj := pw.NewObject()
+	obj.Dictionary = pw.security.dict
+	obj.unencrypted = true
+	if err := obj.Save(); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
@@ -0,0 +1,254 @@
+package pdf
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"encoding/binary"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// aesDecrypt reverses securityHandler.encrypt.
+func aesDecrypt(t *testing.T, key, data []byte) []byte {
+	t.Helper()
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) < 32 || len(data)%aes.BlockSize != 0 {
+		t.Fatalf("invalid cipher text length %d", len(data))
+	}
+	out := make([]byte, len(data)-aes.BlockSize)
+	cipher.NewCBCDecrypter(block, data[:aes.BlockSize]).CryptBlocks(out, data[aes.BlockSize:])
+	return out[:len(out)-int(out[len(out)-1])]
+}
+
+// hexEntry returns the decoded hexadecimal string value of key in s.
+func hexEntry(t *testing.T, s, key string) []byte {
+	t.Helper()
+	m := regexp.MustCompile(`/` + key + ` <([0-9a-f]*)>`).FindStringSubmatch(s)
+	if m == nil {
+		t.Fatalf("no /%s entry", key)
+	}
+	return decodeHexString([]byte(m[1]))
+}
+
+func writeEncryptedTestPDF(t *testing.T, enc *Encryption) string {
+	t.Helper()
+	pw, buf := newTestPDF()
+	if err := pw.SetEncryption(enc); err != nil {
+		t.Fatal(err)
+	}
+	content := pw.NewObject()
+	content.Data.WriteString("BT (Hello) Tj ET")
+	pw.AddPage(content, 0)
+	pw.Outlines = []*Outline{{Title: "Kapitel (1)", Dest: "x"}}
+	if err := pw.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestEncryptionAES256(t *testing.T) {
+	out := writeEncryptedTestPDF(t, &Encryption{
+		UserPassword:  "user",
+		OwnerPassword: "owner",
+		Permissions:   PermissionPrint | PermissionCopy,
+	})
+	for _, want := range []string{"/V 5", "/R 6", "/CFM /AESV3", "/Encrypt ", "/ID [<"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q", want)
+		}
+	}
+	u := hexEntry(t, out, "U")
+	o := hexEntry(t, out, "O")
+	ue := hexEntry(t, out, "UE")
+	if len(u) != 48 || len(o) != 48 || len(ue) != 32 {
+		t.Fatalf("unexpected entry lengths U=%d O=%d UE=%d", len(u), len(o), len(ue))
+	}
+	// Algorithm 11 and 12: authenticate the passwords.
+	if !bytes.Equal(hashR6([]byte("user"), u[32:40], nil), u[:32]) {
+		t.Error("user password does not validate")
+	}
+	if !bytes.Equal(hashR6([]byte("owner"), o[32:40], u), o[:32]) {
+		t.Error("owner password does not validate")
+	}
+	if bytes.Equal(hashR6([]byte("wrong"), u[32:40], nil), u[:32]) {
+		t.Error("wrong password validates")
+	}
+	// Algorithm 2.A: recover the file key from /UE.
+	block, _ := aes.NewCipher(hashR6([]byte("user"), u[40:48], nil))
+	fileKey := make([]byte, 32)
+	cipher.NewCBCDecrypter(block, make([]byte, 16)).CryptBlocks(fileKey, ue)
+
+	// Algorithm 13: /Perms holds the permissions.
+	perms := hexEntry(t, out, "Perms")
+	block, _ = aes.NewCipher(fileKey)
+	block.Decrypt(perms, perms)
+	if string(perms[9:12]) != "adb" {
+		t.Errorf("/Perms does not decrypt with the file key")
+	}
+	p := int32(binary.LittleEndian.Uint32(perms))
+	if Permission(p)&PermissionAll != PermissionPrint|PermissionCopy {
+		t.Errorf("permissions = %b", p)
+	}
+	if m := regexp.MustCompile(`/P (-?\d+)`).FindStringSubmatch(out); m == nil || m[1] != strconv.Itoa(int(p)) {
+		t.Errorf("/P entry does not match /Perms")
+	}
+
+	title := hexEntry(t, out, "Title")
+	if got := string(aesDecrypt(t, fileKey, title)); got != "Kapitel (1)" {
+		t.Errorf("decrypted title = %q", got)
+	}
+	if strings.Contains(out, "Hello") || strings.Contains(out, "Kapitel") {
+		t.Error("plain text found in encrypted output")
+	}
+}
+
+func TestHashR6KnownAnswer(t *testing.T) {
+	// user password validation of a revision 6 file, from the PDF 2.0 tests
+	// of pdf.js (test/unit/crypto_spec.js)
+	salt := []byte{83, 245, 146, 101, 198, 247, 34, 198}
+	want := []byte{
+		94, 230, 205, 75, 166, 99, 250, 76, 219, 128, 17, 85, 57, 17, 33, 164,
+		150, 46, 103, 176, 160, 156, 187, 233, 166, 223, 163, 253, 147, 235, 95, 184,
+	}
+	if got := hashR6([]byte("user"), salt, nil); !bytes.Equal(got, want) {
+		t.Errorf("hashR6 = %v, want %v", got, want)
+	}
+}
+
+func TestEncryptionAES128(t *testing.T) {
+	out := writeEncryptedTestPDF(t, &Encryption{
+		Algorithm:    AES128,
+		UserPassword: "user",
+		Permissions:  PermissionAll,
+	})
+	for _, want := range []string{"/V 4", "/R 4", "/CFM /AESV2"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q", want)
+		}
+	}
+	o := hexEntry(t, out, "O")
+	u := hexEntry(t, out, "U")
+	m := regexp.MustCompile(`/ID \[<([0-9a-f]+)>`).FindStringSubmatch(out)
+	id := decodeHexString([]byte(m[1]))
+	p, _ := strconv.Atoi(regexp.MustCompile(`/P (-?\d+)`).FindStringSubmatch(out)[1])
+
+	// Algorithm 2 and 6: derive the key from the user password and compare
+	// the first 16 bytes of /U.
+	h := md5.New()
+	h.Write(padPassword("user"))
+	h.Write(o)
+	binary.Write(h, binary.LittleEndian, int32(p))
+	h.Write(id)
+	key := h.Sum(nil)
+	for range 50 {
+		s := md5.Sum(key[:16])
+		key = s[:]
+	}
+	key = key[:16]
+	h = md5.New()
+	h.Write(passwordPadding)
+	h.Write(id)
+	if !bytes.Equal(rc4Rounds(key, h.Sum(nil)), u[:16]) {
+		t.Fatal("user password does not validate")
+	}
+
+	sh := &securityHandler{revision: 4, fileKey: key}
+	onum, _ := strconv.Atoi(regexp.MustCompile(`(\d+) 0 obj\n<<\n\s*/Dest`).FindStringSubmatch(out)[1])
+	title := hexEntry(t, out, "Title")
+	if got := string(aesDecrypt(t, sh.objectKey(Objectnumber(onum)), title)); got != "Kapitel (1)" {
+		t.Errorf("decrypted title = %q", got)
+	}
+}
+
+func TestEncryptStrings(t *testing.T) {
+	sh := &securityHandler{revision: 6, fileKey: bytes.Repeat([]byte{1}, 32)}
+	in := `<< /A (a\(b\)c\101) /B <616263> /N /Name#28 /D << /E [(x) 1 0 R] >> >>`
+	out, err := sh.encryptStrings(1, []byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	strs := regexp.MustCompile(`<([0-9a-f]+)>`).FindAllStringSubmatch(string(out), -1)
+	var got []string
+	for _, s := range strs {
+		got = append(got, string(aesDecrypt(t, sh.fileKey, decodeHexString([]byte(s[1])))))
+	}
+	if want := []string{"a(b)cA", "abc", "x"}; strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("decrypted strings = %q, want %q", got, want)
+	}
+	for _, want := range []string{"/N /Name#28", "1 0 R", "<< /E ["} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("output missing %q: %s", want, out)
+		}
+	}
+}
+
+func TestEncryptRawStream(t *testing.T) {
+	sh := &securityHandler{revision: 6, fileKey: bytes.Repeat([]byte{2}, 32)}
+	raw := "<</Type /XObject /T (t) /Length 5>>\nstream\nhello\nendstream"
+	out, err := sh.encryptRaw(7, []byte(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := regexp.MustCompile(`(?s)^(.*)/Length (\d+)>>\nstream\n(.*)\nendstream$`).FindSubmatch(out)
+	if m == nil {
+		t.Fatalf("unexpected raw output %q", out)
+	}
+	if n, _ := strconv.Atoi(string(m[2])); n != len(m[3]) {
+		t.Errorf("/Length %d does not match stream length %d", n, len(m[3]))
+	}
+	if got := string(aesDecrypt(t, sh.fileKey, m[3])); got != "hello" {
+		t.Errorf("decrypted stream = %q", got)
+	}
+	if bytes.Contains(m[1], []byte("(t)")) {
+		t.Error("string in raw dictionary not encrypted")
+	}
+}
+
+func TestEncryptRawStreamKeywordInName(t *testing.T) {
+	sh := &securityHandler{revision: 6, fileKey: bytes.Repeat([]byte{2}, 32)}
+	raw := "<</Type /XObject /Upstream (a stream) /Length 5>>\nstream\nhello\nendstream"
+	out, err := sh.encryptRaw(7, []byte(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := regexp.MustCompile(`(?s)^<</Type /XObject /Upstream <[0-9a-f]+> /Length (\d+)>>\nstream\n(.*)\nendstream$`).FindSubmatch(out)
+	if m == nil {
+		t.Fatalf("unexpected raw output %q", out)
+	}
+	if got := string(aesDecrypt(t, sh.fileKey, m[2])); got != "hello" {
+		t.Errorf("decrypted stream = %q", got)
+	}
+}
+
+func TestEncryptRawStreamIndirectLength(t *testing.T) {
+	sh := &securityHandler{revision: 6, fileKey: bytes.Repeat([]byte{2}, 32)}
+	raw := "<</Length 12 0 R /Filter /FlateDecode>>\nstream\nhello\nendstream"
+	out, err := sh.encryptRaw(7, []byte(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := regexp.MustCompile(`(?s)^<</Length (\d+) /Filter /FlateDecode>>\nstream\n(.*)\nendstream$`).FindSubmatch(out)
+	if m == nil {
+		t.Fatalf("unexpected raw output %q", out)
+	}
+	if n, _ := strconv.Atoi(string(m[1])); n != len(m[2]) {
+		t.Errorf("/Length %d does not match stream length %d", n, len(m[2]))
+	}
+}
+
+func TestSetEncryptionAfterWrite(t *testing.T) {
+	pw, _ := newTestPDF()
+	obj := pw.NewObject()
+	obj.Dict(Dict{"A": "1"})
+	obj.Save()
+	if err := pw.SetEncryption(&Encryption{}); err == nil {
+		t.Error("expected an error when encryption is set after writing")
+	}
+}
@@ -0,0 +1,183 @@
+package pdf
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+)
+
+// blendModes are the blend modes of the PDF specification.
+var blendModes = []Name{
+	"Normal", "Compatible", "Multiply", "Screen", "Overlay", "Darken", "Lighten",
+	"ColorDodge", "ColorBurn", "HardLight", "SoftLight", "Difference",
+	"Exclusion", "Hue", "Saturation", "Color", "Luminosity",
+}
+
+// renderingIntents are the rendering intents of the PDF specification.
+var renderingIntents = []Name{"AbsoluteColorimetric", "RelativeColorimetric", "Saturation", "Perceptual"}
+
+// DashPattern is the dash array and the dash phase of stroked lines. An
+// empty array draws solid lines.
+type DashPattern struct {
+	Array []float64
+	Phase float64
+}
+
+// ExtGState is a graphics state parameter dictionary. Add it to the
+// ExtGStates of a page and set it with /<name> gs in the content stream.
+// Nil and empty fields leave the parameter unchanged. Identical states are
+// written only once per document.
+type ExtGState struct {
+	LineWidth *float64
+	// LineCap is 0 (butt), 1 (round) or 2 (projecting square).
+	LineCap *int
+	// LineJoin is 0 (miter), 1 (round) or 2 (bevel).
+	LineJoin   *int
+	MiterLimit *float64
+	Dash       *DashPattern
+	// StrokeAlpha (/CA) and FillAlpha (/ca) are the constant opacity for
+	// stroking and for all other painting operations, between 0 and 1.
+	StrokeAlpha *float64
+	FillAlpha   *float64
+	// BlendMode is a blend mode such as Multiply or Screen.
+	BlendMode Name
+	// SoftMask is the soft mask dictionary written with PDF.WriteSoftMask.
+	// NoSoftMask removes the soft mask of the current graphics state.
+	SoftMask   Objectnumber
+	NoSoftMask bool
+	// Overprint (/OP) and FillOverprint (/op) enable overprinting for
+	// stroking and for all other painting operations. If FillOverprint is
+	// nil, Overprint applies to both.
+	Overprint     *bool
+	FillOverprint *bool
+	// OverprintMode (/OPM) is 0 or 1. With 1, CMYK components of 0 do not
+	// knock out the colours below.
+	OverprintMode *int
+	// Flatness is the flatness tolerance for curves in device pixels.
+	Flatness *float64
+	// RenderingIntent is AbsoluteColorimetric, RelativeColorimetric,
+	// Saturation or Perceptual.
+	RenderingIntent Name
+}
+
+// dict returns the dictionary of the graphics state.
+func (gs *ExtGState) dict() (Dict, error) {
+	d := Dict{"Type": "/ExtGState"}
+	if gs.LineWidth != nil {
+		if *gs.LineWidth < 0 {
+			return nil, fmt.Errorf("pdf: negative line width %s", fmtPDFFloat(*gs.LineWidth))
+		}
+		d["LW"] = fmtPDFFloat(*gs.LineWidth)
+	}
+	for _, p := range []struct {
+		key Name
+		val *int
+		max int
+	}{{"LC", gs.LineCap, 2}, {"LJ", gs.LineJoin, 2}, {"OPM", gs.OverprintMode, 1}} {
+		if p.val == nil {
+			continue
+		}
+		if *p.val < 0 || *p.val > p.max {
+			return nil, fmt.Errorf("pdf: graphics state %s %d is not between 0 and %d", p.key, *p.val, p.max)
+		}
+		d[p.key] = strconv.Itoa(*p.val)
+	}
+	if gs.MiterLimit != nil {
+		if *gs.MiterLimit < 1 {
+			return nil, fmt.Errorf("pdf: miter limit %s is less than 1", fmtPDFFloat(*gs.MiterLimit))
+		}
+		d["ML"] = fmtPDFFloat(*gs.MiterLimit)
+	}
+	if gs.Dash != nil {
+		if slices.ContainsFunc(gs.Dash.Array, func(f float64) bool { return f < 0 }) {
+			return nil, fmt.Errorf("pdf: negative dash length")
+		}
+		d["D"] = "[" + numberArray(gs.Dash.Array) + " " + fmtPDFFloat(gs.Dash.Phase) + "]"
+	}
+	for _, p := range []struct {
+		key Name
+		val *float64
+	}{{"CA", gs.StrokeAlpha}, {"ca", gs.FillAlpha}} {
+		if p.val == nil {
+			continue
+		}
+		if *p.val < 0 || *p.val > 1 {
+			return nil, fmt.Errorf("pdf: opacity %s is not between 0 and 1", fmtPDFFloat(*p.val))
+		}
+		d[p.key] = fmtPDFFloat(*p.val)
+	}
+	if gs.BlendMode != "" {
+		if !slices.Contains(blendModes, gs.BlendMode) {
+			return nil, fmt.Errorf("pdf: unknown blend mode %q", gs.BlendMode)
+		}
+		d["BM"] = gs.BlendMode.String()
+	}
+	switch {
+	case gs.SoftMask != 0 && gs.NoSoftMask:
+		return nil, fmt.Errorf("pdf: graphics state has a soft mask and NoSoftMask")
+	case gs.SoftMask != 0:
+		d["SMask"] = gs.SoftMask.Ref()
+	case gs.NoSoftMask:
+		d["SMask"] = "/None"
+	}
+	if gs.Overprint != nil {
+		d["OP"] = strconv.FormatBool(*gs.Overprint)
+	}
+	if gs.FillOverprint != nil {
+		d["op"] = strconv.FormatBool(*gs.FillOverprint)
+	}
+	if gs.Flatness != nil {
+		if *gs.Flatness < 0 || *gs.Flatness > 100 {
+			return nil, fmt.Errorf("pdf: flatness %s is not between 0 and 100", fmtPDFFloat(*gs.Flatness))
+		}
+		d["FL"] = fmtPDFFloat(*gs.Flatness)
+	}
+	if gs.RenderingIntent != "" {
+		if !slices.Contains(renderingIntents, gs.RenderingIntent) {
+			return nil, fmt.Errorf("pdf: unknown rendering intent %q", gs.RenderingIntent)
+		}
+		d["RI"] = gs.RenderingIntent.String()
+	}
+	return d, nil
+}
+
+// checkPDFA reports the transparency of the graphics state which PDF/A-1
+// does not allow.
+func (gs *ExtGState) checkPDFA(pw *PDF) {
+	if pw.PDFA == nil || pw.PDFA.Level != PDFA1b {
+		return
+	}
+	if (gs.StrokeAlpha != nil && *gs.StrokeAlpha != 1) || (gs.FillAlpha != nil && *gs.FillAlpha != 1) {
+		pw.conformanceErrors = append(pw.conformanceErrors, fmt.Errorf("pdf: PDF/A-1 does not allow transparency"))
+	}
+	if gs.BlendMode != "" && gs.BlendMode != "Normal" && gs.BlendMode != "Compatible" {
+		pw.conformanceErrors = append(pw.conformanceErrors, fmt.Errorf("pdf: PDF/A-1 does not allow the blend mode %s", gs.BlendMode))
+	}
+	if gs.SoftMask != 0 {
+		pw.conformanceErrors = append(pw.conformanceErrors, fmt.Errorf("pdf: PDF/A-1 does not allow soft masks"))
+	}
+}
+
+// extGStateObject writes the graphics state unless an identical state has
+// been written before and returns its object number.
+func (pw *PDF) extGStateObject(gs *ExtGState) (Objectnumber, error) {
+	d, err := gs.dict()
+	if err != nil {
+		return 0, err
+	}
+	key := hashToString(d, 0)
+	if num, ok := pw.extGStates[key]; ok {
+		return num, nil
+	}
+	gs.checkPDFA(pw)
+	obj := pw.NewObject()
+	obj.Dict(d)
+	if err = obj.Save(); err != nil {
+		return 0, err
+	}
+	if pw.extGStates == nil {
+		pw.extGStates = make(map[string]Objectnumber)
+	}
+	pw.extGStates[key] = obj.ObjectNumber
+	return obj.ObjectNumber, nil
+}
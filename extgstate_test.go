@@ -0,0 +1,107 @@
+package pdf
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestExtGState(t *testing.T) {
+	lw, alpha, flat := 2.5, 0.4, 1.0
+	lc, opm := 1, 1
+	overprint := true
+	gs := &ExtGState{
+		LineWidth:       &lw,
+		LineCap:         &lc,
+		Dash:            &DashPattern{Array: []float64{3, 2}, Phase: 1},
+		StrokeAlpha:     &alpha,
+		FillAlpha:       &alpha,
+		BlendMode:       "Multiply",
+		NoSoftMask:      true,
+		Overprint:       &overprint,
+		OverprintMode:   &opm,
+		Flatness:        &flat,
+		RenderingIntent: "Perceptual",
+	}
+	d, err := gs.dict()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := hashToString(d, 0)
+	for _, want := range []string{
+		"/Type /ExtGState",
+		"/LW 2.5",
+		"/LC 1",
+		"/D [[3 2] 1]",
+		"/CA 0.4",
+		"/ca 0.4",
+		"/BM /Multiply",
+		"/SMask /None",
+		"/OP true",
+		"/OPM 1",
+		"/FL 1",
+		"/RI /Perceptual",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("dict %q does not contain %q", got, want)
+		}
+	}
+	if strings.Contains(got, "/op ") || strings.Contains(got, "/LJ") {
+		t.Errorf("dict %q contains unset entries", got)
+	}
+}
+
+func TestExtGStateDeduplication(t *testing.T) {
+	pw, buf := newTestPDF()
+	alpha := 0.5
+	for range 2 {
+		page := pw.AddPage(pw.NewObject(), 0)
+		page.ExtGStates = map[Name]*ExtGState{"gs1": {FillAlpha: &alpha}}
+	}
+	other := 0.5
+	page := pw.AddPage(pw.NewObject(), 0)
+	page.ExtGStates = map[Name]*ExtGState{"gs2": {FillAlpha: &other}}
+	if err := pw.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if n := strings.Count(out, "/Type /ExtGState"); n != 1 {
+		t.Errorf("%d graphics state objects, want 1", n)
+	}
+	num := pw.extGStates[hashToString(Dict{"Type": "/ExtGState", "ca": "0.5"}, 0)]
+	for _, want := range []string{fmt.Sprintf("/gs1 %s", num.Ref()), fmt.Sprintf("/gs2 %s", num.Ref())} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q", want)
+		}
+	}
+}
+
+func TestExtGStateErrors(t *testing.T) {
+	neg, big, three := -1.0, 2.0, 3
+	for _, gs := range []*ExtGState{
+		{LineWidth: &neg},
+		{LineCap: &three},
+		{LineJoin: &three},
+		{OverprintMode: &three},
+		{MiterLimit: &neg},
+		{Dash: &DashPattern{Array: []float64{-1}}},
+		{StrokeAlpha: &big},
+		{FillAlpha: &neg},
+		{BlendMode: "Burn"},
+		{SoftMask: 5, NoSoftMask: true},
+		{Flatness: &neg},
+		{RenderingIntent: "Vivid"},
+	} {
+		if _, err := gs.dict(); err == nil {
+			t.Errorf("expected an error for %+v", gs)
+		}
+	}
+
+	alpha := 0.5
+	if _, err := writePDFATestPDF(t, &PDFA{Level: PDFA1b, ICCProfile: testICCProfile("RGB ")}, func(pw *PDF) {
+		page := pw.AddPage(pw.NewObject(), 0)
+		page.ExtGStates = map[Name]*ExtGState{"gs1": {FillAlpha: &alpha}}
+	}); err == nil {
+		t.Error("expected an error for transparency in PDF/A-1")
+	}
+}
@@ -0,0 +1,721 @@
+package pdf
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// FieldType is the type of an interactive form field.
+type FieldType int
+
+const (
+	// FieldText is a text field, see FormField.Multiline and
+	// FormField.Password.
+	FieldText FieldType = iota
+	// FieldCheckbox is a check box which is on when its value equals the
+	// export value.
+	FieldCheckbox
+	// FieldRadio is a group of radio buttons. The buttons are the kids of
+	// the field, distinguished by their export values.
+	FieldRadio
+	// FieldCombo is a drop-down list of options.
+	FieldCombo
+	// FieldList is a scrollable list of options.
+	FieldList
+	// FieldPushButton is a button which triggers an action.
+	FieldPushButton
+	// FieldSignature is a signature field. See PDF.AddSignature for signing
+	// the document with it.
+	FieldSignature
+)
+
+// FieldFlags are the field flags (/Ff) that apply to all field types. The
+// flags that depend on the field type are derived from the FormField
+// settings.
+type FieldFlags int
+
+// Field flags that apply to all field types.
+const (
+	FieldFlagReadOnly FieldFlags = 1 << 0
+	FieldFlagRequired FieldFlags = 1 << 1
+	FieldFlagNoExport FieldFlags = 1 << 2
+)
+
+// Type specific field flags.
+const (
+	ffMultiline     = 1 << 12
+	ffPassword      = 1 << 13
+	ffNoToggleToOff = 1 << 14
+	ffRadio         = 1 << 15
+	ffPushbutton    = 1 << 16
+	ffCombo         = 1 << 17
+	ffEdit          = 1 << 18
+	ffMultiSelect   = 1 << 21
+)
+
+// FormField is a field of an interactive form (AcroForm). A field with Kids
+// is a non-terminal node in the field hierarchy that only contributes its
+// name to the fully qualified names of the kids. Terminal fields have a
+// widget annotation on Page at Rect, except for radio button groups, whose
+// kids are the buttons.
+type FormField struct {
+	Type FieldType
+	// Name is the partial field name. The fully qualified name joins the
+	// names of all ancestors with periods.
+	Name string
+	// Tooltip is the alternate field name shown by viewers.
+	Tooltip string
+	// Value is the text of a text field, the selected option of a choice
+	// field or the export value of the selected check box or radio button.
+	Value string
+	// DefaultValue is the value after a form reset.
+	DefaultValue string
+	// Flags holds FieldFlagReadOnly, FieldFlagRequired and
+	// FieldFlagNoExport.
+	Flags     FieldFlags
+	Multiline bool
+	Password  bool
+	MaxLen    int
+	// Options are the entries of a combo box or list box.
+	Options     []string
+	MultiSelect bool
+	// Editable allows entering text not in Options in a combo box.
+	Editable bool
+	// ExportValue is the on state of a check box or radio button. It
+	// defaults to "Yes".
+	ExportValue string
+	// Caption is the label of a push button.
+	Caption string
+	// Action is performed when a push button is clicked.
+	Action *Action
+	// Face is the font of the field text. It is added to the default
+	// resources of the form. Text, choice and push button fields with text
+	// need a face.
+	Face *Face
+	// FontSize is the size of the field text, 0 for automatic sizing.
+	FontSize float64
+	// Align is the alignment of the text: 0 left, 1 centered, 2 right.
+	Align int
+	// Colours have one (gray), three (RGB) or four (CMYK) components. A nil
+	// border or background colour is not painted, the text colour defaults
+	// to black.
+	TextColor       []float64
+	BorderColor     []float64
+	BackgroundColor []float64
+	Page            *Page
+	Rect            [4]float64 // x1, y1, x2, y2
+	Kids            []*FormField
+	objnum          Objectnumber
+	dict            Dict
+}
+
+// AddFormField adds a field including its kids to the interactive form of the
+// document. The appearance streams of the widgets are written immediately, so
+// the field should be complete when it is added.
+func (pw *PDF) AddFormField(f *FormField) error {
+	if err := pw.addFormField(f, nil); err != nil {
+		return err
+	}
+	pw.formFields = append(pw.formFields, f)
+	return nil
+}
+
+func (pw *PDF) addFormField(f *FormField, parent *FormField) error {
+	f.objnum = pw.NextObject()
+	f.dict = Dict{}
+	if parent != nil {
+		f.dict["Parent"] = parent.objnum.Ref()
+	}
+	if f.Name != "" {
+		f.dict["T"] = stringToPDF(f.Name)
+	}
+	if f.Tooltip != "" {
+		f.dict["TU"] = stringToPDF(f.Tooltip)
+	}
+	if f.Type == FieldRadio {
+		return pw.addRadioGroup(f)
+	}
+	if len(f.Kids) > 0 {
+		kids := make([]string, len(f.Kids))
+		for i, kid := range f.Kids {
+			if err := pw.addFormField(kid, f); err != nil {
+				return err
+			}
+			kids[i] = kid.objnum.Ref()
+		}
+		f.dict["Kids"] = "[" + strings.Join(kids, " ") + "]"
+		obj := pw.NewObjectWithNumber(f.objnum)
+		obj.Dict(f.dict)
+		return obj.Save()
+	}
+	if f.Name == "" {
+		return errors.New("pdf: form field without a name")
+	}
+	if f.Page == nil {
+		return fmt.Errorf("pdf: form field %q has no page", f.Name)
+	}
+	if f.Face != nil && !slices.Contains(pw.formFaces, f.Face) {
+		pw.formFaces = append(pw.formFaces, f.Face)
+	}
+	ff := int(f.Flags)
+	switch f.Type {
+	case FieldText:
+		f.dict["FT"] = "/Tx"
+		if f.Multiline {
+			ff |= ffMultiline
+		}
+		if f.Password {
+			ff |= ffPassword
+		}
+		if f.MaxLen > 0 {
+			f.dict["MaxLen"] = strconv.Itoa(f.MaxLen)
+		}
+	case FieldCombo, FieldList:
+		f.dict["FT"] = "/Ch"
+		if f.Type == FieldCombo {
+			ff |= ffCombo
+			if f.Editable {
+				ff |= ffEdit
+			}
+		}
+		if f.MultiSelect {
+			ff |= ffMultiSelect
+		}
+		opts := make(Array, len(f.Options))
+		for i, o := range f.Options {
+			opts[i] = String(o)
+		}
+		f.dict["Opt"] = Serialize(opts)
+	case FieldCheckbox:
+		f.dict["FT"] = "/Btn"
+	case FieldPushButton:
+		f.dict["FT"] = "/Btn"
+		ff |= ffPushbutton
+		if f.Action != nil {
+			if err := pw.checkAction(f.Action); err != nil {
+				return err
+			}
+			f.dict["A"] = f.Action
+		}
+	case FieldSignature:
+		f.dict["FT"] = "/Sig"
+	default:
+		return fmt.Errorf("pdf: unknown form field type %d", f.Type)
+	}
+	if ff != 0 {
+		f.dict["Ff"] = strconv.Itoa(ff)
+	}
+	switch f.Type {
+	case FieldCheckbox:
+		on := f.onState()
+		f.dict["V"] = "/Off"
+		f.dict["AS"] = "/Off"
+		if f.Value == f.exportValue() {
+			f.dict["V"] = on
+			f.dict["AS"] = on
+		}
+		if f.DefaultValue != "" {
+			f.dict["DV"] = Name(f.DefaultValue).String()
+		}
+	case FieldText, FieldCombo, FieldList:
+		if f.Value != "" {
+			f.dict["V"] = stringToPDF(f.Value)
+		}
+		if f.DefaultValue != "" {
+			f.dict["DV"] = stringToPDF(f.DefaultValue)
+		}
+	}
+	if f.Face != nil {
+		f.dict["DA"] = f.defaultAppearance(f.fontSize())
+	}
+	if f.Align != 0 {
+		f.dict["Q"] = strconv.Itoa(f.Align)
+	}
+	if err := pw.writeWidgetAppearance(f); err != nil {
+		return err
+	}
+	f.addWidget(f.dict)
+	return nil
+}
+
+// addRadioGroup writes the field dictionary of a radio button group and adds
+// the widgets of the buttons to their pages.
+func (pw *PDF) addRadioGroup(f *FormField) error {
+	if len(f.Kids) == 0 {
+		return fmt.Errorf("pdf: radio button group %q has no buttons", f.Name)
+	}
+	f.dict["FT"] = "/Btn"
+	f.dict["Ff"] = strconv.Itoa(int(f.Flags) | ffRadio | ffNoToggleToOff)
+	f.dict["V"] = "/Off"
+	if f.Value != "" {
+		f.dict["V"] = Name(f.Value).String()
+	}
+	if f.DefaultValue != "" {
+		f.dict["DV"] = Name(f.DefaultValue).String()
+	}
+	kids := make([]string, len(f.Kids))
+	for i, kid := range f.Kids {
+		if kid.Page == nil {
+			return fmt.Errorf("pdf: radio button %d of %q has no page", i, f.Name)
+		}
+		// the widget is a radio button with the look of the group, the
+		// caller's kid is left untouched
+		widget := *kid
+		widget.Type = FieldRadio
+		if widget.BorderColor == nil {
+			widget.BorderColor = f.BorderColor
+		}
+		if widget.BackgroundColor == nil {
+			widget.BackgroundColor = f.BackgroundColor
+		}
+		if widget.TextColor == nil {
+			widget.TextColor = f.TextColor
+		}
+		widget.objnum = pw.NextObject()
+		widget.dict = Dict{
+			"Parent": f.objnum.Ref(),
+			"AS":     "/Off",
+		}
+		if f.Value != "" && widget.exportValue() == f.Value {
+			widget.dict["AS"] = widget.onState()
+		}
+		if err := pw.writeWidgetAppearance(&widget); err != nil {
+			return err
+		}
+		widget.addWidget(widget.dict)
+		kids[i] = widget.objnum.Ref()
+	}
+	f.dict["Kids"] = "[" + strings.Join(kids, " ") + "]"
+	obj := pw.NewObjectWithNumber(f.objnum)
+	obj.Dict(f.dict)
+	return obj.Save()
+}
+
+// addWidget adds the widget annotation of the field to its page. For terminal
+// fields with a single widget the field and widget dictionaries are merged.
+func (f *FormField) addWidget(d Dict) {
+	d["P"] = f.Page.Objnum.Ref()
+	d["F"] = "4" // print
+	mk := Dict{}
+	if f.BorderColor != nil {
+		mk["BC"] = colorArray(f.BorderColor)
+	}
+	if f.BackgroundColor != nil {
+		mk["BG"] = colorArray(f.BackgroundColor)
+	}
+	if f.Caption != "" {
+		mk["CA"] = stringToPDF(f.Caption)
+	}
+	if len(mk) > 0 {
+		d["MK"] = mk
+	}
+	f.Page.Annotations = append(f.Page.Annotations, Annotation{
+		Subtype:      "Widget",
+		Rect:         f.Rect,
+		Objectnumber: f.objnum,
+		Dictionary:   d,
+	})
+}
+
+func (f *FormField) exportValue() string {
+	if f.ExportValue == "" {
+		return "Yes"
+	}
+	return f.ExportValue
+}
+
+func (f *FormField) onState() string {
+	return Name(f.exportValue()).String()
+}
+
+func (f *FormField) size() (float64, float64) {
+	return f.Rect[2] - f.Rect[0], f.Rect[3] - f.Rect[1]
+}
+
+// fontSize returns the font size of the field, computing a size that fits
+// the height of single line fields if FontSize is 0.
+func (f *FormField) fontSize() float64 {
+	if f.FontSize > 0 {
+		return f.FontSize
+	}
+	_, h := f.size()
+	if f.Multiline || f.Type == FieldList || f.Face == nil {
+		return 12
+	}
+	upem := float64(f.Face.UnitsPerEM)
+	lineHeight := float64(f.Face.face.Ascender()-f.Face.face.Descender()) / upem
+	return min(12, max(4, (h-4)/lineHeight))
+}
+
+func (f *FormField) defaultAppearance(size float64) string {
+	color := f.TextColor
+	if color == nil {
+		color = []float64{0}
+	}
+	return stringToPDF(fmt.Sprintf("%s %s Tf %s", f.Face.InternalName(), fmtPDFFloat(size), colorOperator(color, false)))
+}
+
+// colorArray returns the colour as a PDF array.
+func colorArray(c []float64) string {
+	return numberArray(c)
+}
+
+// colorOperator returns the operator that sets the colour for filling or
+// stroking, depending on the number of components.
+func colorOperator(c []float64, stroke bool) string {
+	ops := map[int]string{1: "g", 3: "rg", 4: "k"}
+	op := ops[len(c)]
+	if stroke {
+		op = strings.ToUpper(op)
+	}
+	s := make([]string, len(c))
+	for i, v := range c {
+		s[i] = fmtPDFFloat(v)
+	}
+	return strings.Join(s, " ") + " " + op
+}
+
+// darken returns the colour of a pressed widget.
+func darken(c []float64) []float64 {
+	if c == nil {
+		return []float64{0.75}
+	}
+	d := make([]float64, len(c))
+	for i, v := range c {
+		d[i] = v * 0.75
+	}
+	if len(c) == 4 {
+		copy(d, c)
+		d[3] = c[3] + (1-c[3])*0.25
+	}
+	return d
+}
+
+// circlePath returns the path of a circle approximated by four Bézier curves.
+func circlePath(cx, cy, r float64) string {
+	return ellipsePath(cx, cy, r, r)
+}
+
+// ellipsePath returns the path of an ellipse approximated by four Bézier
+// curves.
+func ellipsePath(cx, cy, rx, ry float64) string {
+	const kappa = 0.5523
+	kx, ky := rx*kappa, ry*kappa
+	f := fmtPDFFloat
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s m\n", f(cx+rx), f(cy))
+	fmt.Fprintf(&b, "%s %s %s %s %s %s c\n", f(cx+rx), f(cy+ky), f(cx+kx), f(cy+ry), f(cx), f(cy+ry))
+	fmt.Fprintf(&b, "%s %s %s %s %s %s c\n", f(cx-kx), f(cy+ry), f(cx-rx), f(cy+ky), f(cx-rx), f(cy))
+	fmt.Fprintf(&b, "%s %s %s %s %s %s c\n", f(cx-rx), f(cy-ky), f(cx-kx), f(cy-ry), f(cx), f(cy-ry))
+	fmt.Fprintf(&b, "%s %s %s %s %s %s c\n", f(cx+kx), f(cy-ry), f(cx+rx), f(cy-ky), f(cx+rx), f(cy))
+	return b.String()
+}
+
+// background returns the drawing operators for the background and the border
+// of the widget.
+func (f *FormField) background(down bool) string {
+	w, h := f.size()
+	bg := f.BackgroundColor
+	if down {
+		bg = darken(bg)
+	}
+	round := f.Type == FieldRadio
+	var b strings.Builder
+	if bg != nil {
+		b.WriteString(colorOperator(bg, false) + "\n")
+		if round {
+			b.WriteString(circlePath(w/2, h/2, min(w, h)/2) + "f\n")
+		} else {
+			fmt.Fprintf(&b, "0 0 %s %s re f\n", fmtPDFFloat(w), fmtPDFFloat(h))
+		}
+	}
+	if f.BorderColor != nil {
+		b.WriteString(colorOperator(f.BorderColor, true) + "\n1 w\n")
+		if round {
+			b.WriteString(circlePath(w/2, h/2, min(w, h)/2-0.5) + "s\n")
+		} else {
+			fmt.Fprintf(&b, "0.5 0.5 %s %s re s\n", fmtPDFFloat(w-1), fmtPDFFloat(h-1))
+		}
+	}
+	return b.String()
+}
+
+// encodeText returns the glyphs of s as a hex string for the face and the
+// width of the text at the given size. The glyphs are registered for
+// subsetting.
+func encodeText(face *Face, s string, size float64) (string, float64) {
+	var b strings.Builder
+	b.WriteByte('<')
+	wd := 0.0
+	for _, r := range s {
+		gid := face.Codepoint(r)
+		face.RegisterCodepoint(gid)
+		fmt.Fprintf(&b, "%04x", gid)
+		wd += face.AdvanceWidth(gid) * size
+	}
+	b.WriteByte('>')
+	return b.String(), wd
+}
+
+// textWidth returns the width of s at the given size.
+func textWidth(face *Face, s string, size float64) float64 {
+	wd := 0.0
+	for _, r := range s {
+		wd += face.AdvanceWidth(face.Codepoint(r)) * size
+	}
+	return wd
+}
+
+// wrapText breaks s into lines that fit into width.
+func wrapText(face *Face, s string, size, width float64) []string {
+	var lines []string
+	for _, para := range strings.Split(s, "\n") {
+		line := ""
+		for _, word := range strings.FieldsFunc(para, unicode.IsSpace) {
+			candidate := word
+			if line != "" {
+				candidate = line + " " + word
+			}
+			if line != "" && textWidth(face, candidate, size) > width {
+				lines = append(lines, line)
+				line = word
+			} else {
+				line = candidate
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// textAppearance returns the variable text part of the appearance: the lines
+// of text in the field's face with the selected lines of a list box
+// highlighted.
+func (f *FormField) textAppearance(lines []string, selected map[int]bool, size float64) string {
+	w, h := f.size()
+	const padding = 2
+	upem := float64(f.Face.UnitsPerEM)
+	asc := float64(f.Face.face.Ascender()) / upem * size
+	desc := float64(f.Face.face.Descender()) / upem * size
+	leading := (asc - desc) * 1.15
+	var y float64
+	if len(lines) == 1 && !f.Multiline && f.Type != FieldList {
+		y = (h-(asc-desc))/2 - desc
+	} else {
+		y = h - padding - asc
+	}
+	color := f.TextColor
+	if color == nil {
+		color = []float64{0}
+	}
+	var b strings.Builder
+	b.WriteString("/Tx BMC\nq\n")
+	fmt.Fprintf(&b, "1 1 %s %s re W n\n", fmtPDFFloat(w-2), fmtPDFFloat(h-2))
+	for i := range lines {
+		if selected[i] {
+			bottom := y - float64(i)*leading + desc - (leading-(asc-desc))/2
+			fmt.Fprintf(&b, "0.6 0.75 0.85 rg\n1 %s %s %s re f\n",
+				fmtPDFFloat(bottom), fmtPDFFloat(w-2), fmtPDFFloat(leading))
+		}
+	}
+	fmt.Fprintf(&b, "BT\n%s %s Tf\n%s\n", f.Face.InternalName(), fmtPDFFloat(size), colorOperator(color, false))
+	for i, line := range lines {
+		hex, tw := encodeText(f.Face, line, size)
+		x := float64(padding)
+		switch f.Align {
+		case 1:
+			x = (w - tw) / 2
+		case 2:
+			x = w - padding - tw
+		}
+		fmt.Fprintf(&b, "1 0 0 1 %s %s Tm\n%s Tj\n", fmtPDFFloat(x), fmtPDFFloat(y-float64(i)*leading), hex)
+	}
+	b.WriteString("ET\nQ\nEMC\n")
+	return b.String()
+}
+
+// appearance returns the content of the appearance stream of the field in the
+// given state.
+func (f *FormField) appearance(on, down bool) (string, error) {
+	w, h := f.size()
+	content := f.background(down)
+	switch f.Type {
+	case FieldCheckbox, FieldRadio:
+		if !on {
+			return content, nil
+		}
+		color := f.TextColor
+		if color == nil {
+			color = []float64{0}
+		}
+		s := min(w, h)
+		if f.Type == FieldRadio {
+			return content + colorOperator(color, false) + "\n" + circlePath(w/2, h/2, s/4) + "f\n", nil
+		}
+		p := func(x, y float64) string {
+			return fmtPDFFloat((w-s)/2+x*s) + " " + fmtPDFFloat((h-s)/2+y*s)
+		}
+		return content + fmt.Sprintf("%s\n%s w\n1 J\n1 j\n%s m\n%s l\n%s l\nS\n",
+			colorOperator(color, true), fmtPDFFloat(s/10), p(0.2, 0.5), p(0.4, 0.25), p(0.8, 0.75)), nil
+	case FieldSignature:
+		return content, nil
+	}
+	text := f.Value
+	if f.Type == FieldPushButton {
+		// captions are centered
+		button := *f
+		button.Align = 1
+		f = &button
+		text = f.Caption
+	}
+	if f.Type != FieldList && text == "" {
+		return content, nil
+	}
+	if f.Face == nil {
+		return "", fmt.Errorf("pdf: form field %q needs a face", f.Name)
+	}
+	size := f.fontSize()
+	if f.Password {
+		text = strings.Repeat("*", len([]rune(text)))
+	}
+	var lines []string
+	var selected map[int]bool
+	switch {
+	case f.Type == FieldList:
+		lines = f.Options
+		selected = make(map[int]bool)
+		for i, o := range f.Options {
+			if o == f.Value {
+				selected[i] = true
+			}
+		}
+	case f.Multiline:
+		lines = wrapText(f.Face, text, size, w-4)
+	default:
+		if f.FontSize == 0 {
+			if tw := textWidth(f.Face, text, size); tw > w-4 {
+				size = max(4, size*(w-4)/tw)
+			}
+		}
+		lines = []string{text}
+	}
+	return content + f.textAppearance(lines, selected, size), nil
+}
+
+// writeWidgetAppearance writes the normal, rollover and down appearances of
+// the widget and sets /AP. Check boxes and radio buttons get an appearance
+// for the on and the off state.
+func (pw *PDF) writeWidgetAppearance(widget *FormField) error {
+	var resources string
+	if widget.Face != nil {
+		resources = "<< /Font << " + widget.Face.InternalName() + " " + widget.Face.fontobject.ObjectNumber.Ref() + " >> >>"
+	}
+	write := func(on, down bool) (string, error) {
+		content, err := widget.appearance(on, down)
+		if err != nil {
+			return "", err
+		}
+		w, h := widget.size()
+		ap := pw.NewObject()
+		ap.Dictionary = Dict{
+			"Type":    "/XObject",
+			"Subtype": "/Form",
+			"BBox":    fmt.Sprintf("[0 0 %s %s]", fmtPDFFloat(w), fmtPDFFloat(h)),
+		}
+		if resources != "" {
+			ap.Dictionary["Resources"] = resources
+		}
+		ap.Data.WriteString(content)
+		ap.ForceStream = true
+		ap.SetCompression(9)
+		if err := ap.Save(); err != nil {
+			return "", err
+		}
+		return ap.ObjectNumber.Ref(), nil
+	}
+	switch widget.Type {
+	case FieldCheckbox, FieldRadio:
+		states := make(map[bool]map[bool]string)
+		for _, down := range []bool{false, true} {
+			states[down] = make(map[bool]string)
+			for _, on := range []bool{true, false} {
+				ref, err := write(on, down)
+				if err != nil {
+					return err
+				}
+				states[down][on] = ref
+			}
+		}
+		state := func(down bool) string {
+			return fmt.Sprintf("<< %s %s /Off %s >>", widget.onState(), states[down][true], states[down][false])
+		}
+		widget.dict["AP"] = fmt.Sprintf("<< /N %s /R %s /D %s >>", state(false), state(false), state(true))
+	default:
+		normal, err := write(false, false)
+		if err != nil {
+			return err
+		}
+		down := normal
+		if widget.Type == FieldPushButton {
+			if down, err = write(false, true); err != nil {
+				return err
+			}
+		}
+		widget.dict["AP"] = fmt.Sprintf("<< /N %s /R %s /D %s >>", normal, normal, down)
+	}
+	return nil
+}
+
+// findFormField returns the terminal field with the fully qualified name.
+func (pw *PDF) findFormField(name string) *FormField {
+	var find func(fields []*FormField, prefix string) *FormField
+	find = func(fields []*FormField, prefix string) *FormField {
+		for _, f := range fields {
+			full := prefix + f.Name
+			if len(f.Kids) > 0 && f.Type != FieldRadio {
+				if found := find(f.Kids, full+"."); found != nil {
+					return found
+				}
+			} else if full == name {
+				return f
+			}
+		}
+		return nil
+	}
+	return find(pw.formFields, "")
+}
+
+// acroForm returns the /AcroForm entry of the catalog, nil if the document
+// has no form fields.
+func (pw *PDF) acroForm() Dict {
+	var fields []string
+	for _, f := range pw.formFields {
+		fields = append(fields, f.objnum.Ref())
+	}
+	sig := pw.signature
+	if sig != nil && pw.findFormField(sig.FieldName) == nil {
+		fields = append(fields, sig.widget.Ref())
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	af := Dict{
+		"Fields": "[" + strings.Join(fields, " ") + "]",
+	}
+	if sig != nil {
+		af["SigFlags"] = strconv.Itoa(3) // signatures exist, append only
+	}
+	if len(pw.formFaces) > 0 {
+		fonts := Dict{}
+		for _, face := range pw.formFaces {
+			fonts[Name(face.InternalName())] = face.fontobject.ObjectNumber.Ref()
+		}
+		af["DR"] = Dict{"Font": fonts}
+		af["DA"] = stringToPDF(pw.formFaces[0].InternalName() + " 0 Tf 0 g")
+	}
+	return af
+}
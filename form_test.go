@@ -0,0 +1,207 @@
+package pdf
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// loadTestFace loads a small Latin subset of Roboto (Apache License 2.0).
+func loadTestFace(t *testing.T, pw *PDF) *Face {
+	t.Helper()
+	data, err := os.ReadFile("testdata/roboto-subset.ttf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	face, err := pw.NewFaceFromData(data, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return face
+}
+
+func TestFormFields(t *testing.T) {
+	pw, buf := newTestPDF()
+	face := loadTestFace(t, pw)
+	page := pw.AddPage(pw.NewObject(), 0)
+	person := &FormField{
+		Name: "person",
+		Kids: []*FormField{
+			{Type: FieldText, Name: "name", Value: "Jane", Face: face, Page: page, Rect: [4]float64{10, 700, 210, 720}, BorderColor: []float64{0}},
+			{Type: FieldText, Name: "notes", Value: "several words that need wrapping", Multiline: true, Face: face, FontSize: 10, Page: page, Rect: [4]float64{10, 600, 110, 690}},
+		},
+	}
+	fields := []*FormField{
+		person,
+		{Type: FieldCheckbox, Name: "agree", Value: "Yes", Page: page, Rect: [4]float64{10, 570, 22, 582}, BackgroundColor: []float64{1, 1, 1}},
+		{Type: FieldRadio, Name: "size", Value: "M", Kids: []*FormField{
+			{ExportValue: "S", Page: page, Rect: [4]float64{10, 540, 22, 552}},
+			{ExportValue: "M", Page: page, Rect: [4]float64{30, 540, 42, 552}},
+		}},
+		{Type: FieldCombo, Name: "color", Options: []string{"red", "green"}, Value: "green", Face: face, Page: page, Rect: [4]float64{10, 500, 110, 520}},
+		{Type: FieldList, Name: "fruit", Options: []string{"apple", "pear"}, Value: "pear", MultiSelect: true, Face: face, Page: page, Rect: [4]float64{10, 440, 110, 490}},
+		{Type: FieldPushButton, Name: "reset", Caption: "Reset", Action: &Action{Type: ActionResetForm}, Face: face, Page: page, Rect: [4]float64{10, 400, 110, 420}, BackgroundColor: []float64{0.8, 0.8, 0.8}},
+	}
+	for _, f := range fields {
+		if err := pw.AddFormField(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := pw.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"/AcroForm",
+		"/DR <<",
+		"/Font <<",
+		"/T (person)",
+		"/Parent " + person.objnum.Ref(),
+		"/FT /Tx",
+		"/V (Jane)",
+		"/DA (" + face.InternalName(),
+		"/Ff 4096", // multiline
+		"/FT /Btn",
+		"/AS /Yes",
+		"/Ff 49152", // radio, no toggle to off
+		"/V /M",
+		"/AS /M",
+		"/FT /Ch",
+		"/Opt [ (red) (green) ]",
+		"/Ff 131072",  // combo
+		"/Ff 2097152", // multi select list
+		"/Ff 65536",   // push button
+		"/S /ResetForm",
+		"/CA (Reset)",
+		"/Subtype /Widget",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q", want)
+		}
+	}
+	if strings.Contains(out, "NeedAppearances") {
+		t.Error("form should not need appearances")
+	}
+	ap := regexp.MustCompile(`/AP << /N (\d+ 0 R|<< [^>]*>>) /R (\d+ 0 R|<< [^>]*>>) /D (\d+ 0 R|<< [^>]*>>) >>`)
+	// name, notes, agree, 2 radio buttons, color, fruit, reset
+	if n := len(ap.FindAllString(out, -1)); n != 8 {
+		t.Errorf("got %d widgets with N/R/D appearances, want 8", n)
+	}
+	if !regexp.MustCompile(`/Fields \[\d+ 0 R \d+ 0 R \d+ 0 R \d+ 0 R \d+ 0 R \d+ 0 R\]`).MatchString(out) {
+		t.Error("expected six root fields")
+	}
+}
+
+func TestFormFieldAppearanceText(t *testing.T) {
+	pw, _ := newTestPDF()
+	face := loadTestFace(t, pw)
+	page := pw.AddPage(pw.NewObject(), 0)
+	f := &FormField{Type: FieldText, Name: "t", Multiline: true, Value: "aaa bbb ccc", Face: face, FontSize: 10, Page: page, Rect: [4]float64{0, 0, 40, 50}}
+	content, err := f.appearance(false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(content, "/Tx BMC") || !strings.Contains(content, "EMC") {
+		t.Error("variable text must be marked with /Tx BMC ... EMC")
+	}
+	if strings.Contains(content, "<0000") {
+		t.Error("text is not mapped to glyphs")
+	}
+	if n := strings.Count(content, " Tj"); n < 2 {
+		t.Errorf("got %d lines, want the text to wrap", n)
+	}
+	f = &FormField{Type: FieldText, Name: "nofont", Value: "x", Page: page, Rect: [4]float64{0, 0, 40, 50}}
+	if _, err := f.appearance(false, false); err == nil {
+		t.Error("expected an error for a text field without a face")
+	}
+}
+
+func TestFormFieldFlags(t *testing.T) {
+	pw, buf := newTestPDF()
+	page := pw.AddPage(pw.NewObject(), 0)
+	radio := &FormField{ExportValue: "S", Page: page, Rect: [4]float64{10, 540, 22, 552}}
+	button := &FormField{Type: FieldPushButton, Name: "go", Page: page, Rect: [4]float64{10, 400, 110, 420}}
+	for _, f := range []*FormField{
+		{Type: FieldCheckbox, Name: "agree", Flags: FieldFlagReadOnly | FieldFlagRequired, Page: page, Rect: [4]float64{10, 570, 22, 582}},
+		{Type: FieldRadio, Name: "size", BorderColor: []float64{0}, Kids: []*FormField{radio}},
+		button,
+	} {
+		if err := pw.AddFormField(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := pw.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "/Ff 3") {
+		t.Error("output does not contain the read only and required flags")
+	}
+	if radio.Type != 0 || radio.BorderColor != nil {
+		t.Error("writing the radio group modified its kid")
+	}
+	if button.Align != 0 {
+		t.Error("writing the push button modified its alignment")
+	}
+}
+
+func TestFormFieldErrors(t *testing.T) {
+	pw, _ := newTestPDF()
+	page := pw.AddPage(pw.NewObject(), 0)
+	for _, f := range []*FormField{
+		{Type: FieldText, Page: page},
+		{Type: FieldText, Name: "nopage"},
+		{Type: FieldRadio, Name: "empty"},
+		{Type: FieldRadio, Name: "r", Kids: []*FormField{{ExportValue: "a"}}},
+	} {
+		if err := pw.AddFormField(f); err == nil {
+			t.Errorf("expected an error for field %q", f.Name)
+		}
+	}
+}
+
+func TestFormSignatureField(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pw, buf := newTestPDF()
+	page := pw.AddPage(pw.NewObject(), 0)
+	field := &FormField{Type: FieldSignature, Name: "approval", Page: page, Rect: [4]float64{10, 10, 110, 40}}
+	if err := pw.AddFormField(&FormField{Name: "sigs", Kids: []*FormField{field}}); err != nil {
+		t.Fatal(err)
+	}
+	sig := &Signature{
+		Signer:       key,
+		Certificates: []*x509.Certificate{selfSignedCertificate(t, key)},
+		FieldName:    "sigs.approval",
+	}
+	if err := pw.AddSignature(nil, sig); err != nil {
+		t.Fatal(err)
+	}
+	if err := pw.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if n := strings.Count(out, "/FT /Sig"); n != 1 {
+		t.Errorf("got %d signature fields, want 1", n)
+	}
+	if !strings.Contains(out, "/V "+sig.sigObj.Ref()) || !strings.Contains(out, "/SigFlags 3") {
+		t.Error("signature field is not signed")
+	}
+	verifySignedPDF(t, buf.Bytes())
+
+	pw, _ = newTestPDF()
+	page = pw.AddPage(pw.NewObject(), 0)
+	if err := pw.AddFormField(&FormField{Type: FieldCheckbox, Name: "box", Page: page}); err != nil {
+		t.Fatal(err)
+	}
+	err = pw.AddSignature(page, &Signature{Signer: key, Certificates: sig.Certificates, FieldName: "box"})
+	if err == nil {
+		t.Error("expected an error when signing a check box")
+	}
+}
@@ -0,0 +1,131 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+)
+
+// TransparencyGroup makes a Form XObject or a page a transparency group
+// (Section 11.6.6 of ISO 32000-1).
+type TransparencyGroup struct {
+	// ColorSpace is the blending colour space such as /DeviceRGB. Empty
+	// uses the colour space of the parent group.
+	ColorSpace Name
+	// Isolated groups are composited on a transparent backdrop instead of
+	// the content below.
+	Isolated bool
+	// Knockout groups composite each object with the backdrop of the group
+	// rather than with the objects painted before.
+	Knockout bool
+}
+
+// dict returns the group attributes dictionary.
+func (g *TransparencyGroup) dict() Dict {
+	d := Dict{"Type": "/Group", "S": "/Transparency"}
+	if g.ColorSpace != "" {
+		d["CS"] = string(g.ColorSpace)
+	}
+	if g.Isolated {
+		d["I"] = "true"
+	}
+	if g.Knockout {
+		d["K"] = "true"
+	}
+	return d
+}
+
+// FormXObject is a self-contained content stream, such as a letterhead or
+// page background, which is written once and can be painted any number of
+// times with "<InternalName> Do". Create it with PDF.NewFormXObject, write
+// the drawing operators to Data and add it to the Forms of a page or of
+// other resources. Form XObjects are written when the document is finished.
+type FormXObject struct {
+	// BBox is the bounding box [llx lly urx ury] in form space, the
+	// content is clipped to it.
+	BBox [4]float64
+	// Matrix maps form space to the user space of the content which paints
+	// the form. The zero matrix is the identity.
+	Matrix    [6]float64 // PDF order: a b c d e f
+	Data      *bytes.Buffer
+	Resources Resources
+	// Group makes the form a transparency group.
+	Group *TransparencyGroup
+	// Objnum is the object number of the form.
+	Objnum       Objectnumber
+	id           int
+	structParent *int
+	oc           OptionalContent
+}
+
+// NewFormXObject returns an empty Form XObject with the bounding box.
+func (pw *PDF) NewFormXObject(bbox [4]float64) *FormXObject {
+	fx := &FormXObject{
+		BBox:   bbox,
+		Data:   &bytes.Buffer{},
+		Objnum: pw.NextObject(),
+		id:     pw.nextID(),
+	}
+	pw.formXObjects = append(pw.formXObjects, fx)
+	return fx
+}
+
+// InternalName returns a PDF usable name such as /Fm1
+func (fx *FormXObject) InternalName() string {
+	return fmt.Sprintf("/Fm%d", fx.id)
+}
+
+// SetStructParent sets the /StructParent entry of the form, the key of the
+// structure element the form belongs to in the document's ParentTree.
+func (fx *FormXObject) SetStructParent(idx int) {
+	fx.structParent = &idx
+}
+
+// SetOptionalContent makes the form visible only if the optional content
+// group or membership dictionary is visible, wherever the form is placed.
+func (fx *FormXObject) SetOptionalContent(oc OptionalContent) {
+	fx.oc = oc
+}
+
+// writeFormXObjects writes all Form XObjects of the document.
+func (pw *PDF) writeFormXObjects() error {
+	for _, fx := range pw.formXObjects {
+		if fx.BBox[0] == fx.BBox[2] || fx.BBox[1] == fx.BBox[3] {
+			return fmt.Errorf("pdf: form XObject %s has an empty BBox", fx.InternalName())
+		}
+		res, err := pw.resourceDict(fx.Resources)
+		if err != nil {
+			return err
+		}
+		pw.useResources(fx.Resources)
+		d := Dict{
+			"Type":    "/XObject",
+			"Subtype": "/Form",
+			"BBox":    numberArray(fx.BBox[:]),
+		}
+		if len(res) > 0 {
+			d["Resources"] = res
+		}
+		if fx.Matrix != [6]float64{} {
+			d["Matrix"] = numberArray(fx.Matrix[:])
+		}
+		if fx.Group != nil {
+			d["Group"] = fx.Group.dict()
+		}
+		if fx.structParent != nil {
+			d["StructParent"] = strconv.Itoa(*fx.structParent)
+		}
+		if fx.oc != nil {
+			d["OC"] = fx.oc.ocObjectnumber().Ref()
+		}
+		obj := pw.NewObjectWithNumber(fx.Objnum)
+		obj.Dictionary = d
+		obj.Data = fx.Data
+		obj.ForceStream = true
+		obj.SetCompression(9)
+		if err = obj.Save(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
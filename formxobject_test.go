@@ -0,0 +1,77 @@
+package pdf
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestFormXObject(t *testing.T) {
+	pw, buf := newTestPDF()
+	img, err := pw.LoadImageFile(writeTempPNG(t, t.TempDir(), 4, 4, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	logo := pw.NewFormXObject([4]float64{0, 0, 20, 20})
+	logo.Data.WriteString("q 20 0 0 20 0 0 cm " + img.InternalName() + " Do Q")
+	logo.Resources.Images = []*Imagefile{img}
+
+	letterhead := pw.NewFormXObject([4]float64{0, 0, 595, 100})
+	letterhead.Matrix = [6]float64{1, 0, 0, 1, 0, 742}
+	letterhead.Group = &TransparencyGroup{ColorSpace: "/DeviceRGB", Isolated: true, Knockout: true}
+	letterhead.SetStructParent(3)
+	layer := pw.NewOptionalContentGroup("Letterhead")
+	letterhead.SetOptionalContent(layer)
+	letterhead.Data.WriteString("0 0 595 2 re f " + logo.InternalName() + " Do")
+	letterhead.Resources.Forms = []*FormXObject{logo}
+
+	for range 2 {
+		content := pw.NewObject()
+		content.Data.WriteString(letterhead.InternalName() + " Do")
+		page := pw.AddPage(content, 0)
+		page.Forms = []*FormXObject{letterhead}
+	}
+	if err := pw.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if n := strings.Count(out, "/Subtype /Form"); n != 2 {
+		t.Errorf("%d form XObjects, want 2", n)
+	}
+	for _, want := range []string{
+		fmt.Sprintf("%s %s", letterhead.InternalName(), letterhead.Objnum.Ref()),
+		"/BBox [0 0 595 100]",
+		"/Matrix [1 0 0 1 0 742]",
+		"/S /Transparency",
+		"/CS /DeviceRGB",
+		"/I true",
+		"/K true",
+		"/StructParent 3",
+		fmt.Sprintf("/OC %s", layer.Objnum.Ref()),
+		fmt.Sprintf("%s %s", logo.InternalName(), logo.Objnum.Ref()),
+		fmt.Sprintf("%s %s", img.InternalName(), img.ImageObject().ObjectNumber.Ref()),
+		"/Subtype /Image",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q", want)
+		}
+	}
+	if _, data := streamData(t, buf.Bytes(), int(logo.Objnum)); !strings.HasSuffix(string(data), img.InternalName()+" Do Q") {
+		t.Errorf("form content = %q", data)
+	}
+}
+
+func TestFormXObjectErrors(t *testing.T) {
+	pw, _ := newTestPDF()
+	pw.NewFormXObject([4]float64{0, 0, 0, 10})
+	pw.AddPage(pw.NewObject(), 0)
+	if err := pw.Finish(); err == nil {
+		t.Error("expected an error for an empty BBox")
+	}
+
+	if _, err := writePDFATestPDF(t, &PDFA{Level: PDFA1b, ICCProfile: testICCProfile("RGB ")}, func(pw *PDF) {
+		pw.NewFormXObject([4]float64{0, 0, 10, 10}).Group = &TransparencyGroup{}
+	}); err == nil {
+		t.Error("expected an error for a transparency group in PDF/A-1")
+	}
+}
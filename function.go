@@ -0,0 +1,215 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// calculatorOperators are the operators allowed in a PostScript calculator
+// function (Table 42 of ISO 32000-1). if and ifelse are handled separately.
+var calculatorOperators = []string{
+	"abs", "add", "atan", "ceiling", "cos", "cvi", "cvr", "div", "exp", "floor",
+	"idiv", "ln", "log", "mod", "mul", "neg", "round", "sin", "sqrt", "sub",
+	"truncate", "and", "bitshift", "eq", "false", "ge", "gt", "le", "lt", "ne",
+	"not", "or", "true", "xor", "copy", "dup", "exch", "index", "pop", "roll",
+}
+
+// bitWriter packs values of any bit width into bytes, most significant bit
+// first.
+type bitWriter struct {
+	w    *bytes.Buffer
+	acc  uint64
+	bits int
+}
+
+// write adds the lowest n bits of v (n <= 32).
+func (bw *bitWriter) write(v uint64, n int) {
+	bw.acc = bw.acc<<n | v&(1<<n-1)
+	bw.bits += n
+	for bw.bits >= 8 {
+		bw.bits -= 8
+		bw.w.WriteByte(byte(bw.acc >> bw.bits))
+	}
+	bw.acc &= 1<<bw.bits - 1
+}
+
+// align pads the last byte with zero bits.
+func (bw *bitWriter) align() {
+	if bw.bits > 0 {
+		bw.write(0, 8-bw.bits)
+	}
+}
+
+// checkIntervals makes sure that the values are min/max pairs.
+func checkIntervals(key string, values []float64) error {
+	if len(values) == 0 || len(values)%2 != 0 {
+		return fmt.Errorf("pdf: function %s needs min/max pairs, got %d values", key, len(values))
+	}
+	for i := 0; i < len(values); i += 2 {
+		if values[i] > values[i+1] {
+			return fmt.Errorf("pdf: function %s min %s is greater than max %s", key, fmtPDFFloat(values[i]), fmtPDFFloat(values[i+1]))
+		}
+	}
+	return nil
+}
+
+// writeSampledFunction writes a type 0 function with the samples packed
+// into a compressed stream.
+func (pw *PDF) writeSampledFunction(f Function) (*Object, error) {
+	outputs, err := f.outputs()
+	if err != nil {
+		return nil, err
+	}
+	if len(f.Size) != f.inputs() {
+		return nil, fmt.Errorf("pdf: sampled function needs %d Size values, got %d", f.inputs(), len(f.Size))
+	}
+	count := outputs
+	for _, s := range f.Size {
+		if s < 1 {
+			return nil, fmt.Errorf("pdf: sampled function Size %d is not positive", s)
+		}
+		count *= s
+	}
+	switch f.BitsPerSample {
+	case 1, 2, 4, 8, 12, 16, 24, 32:
+	default:
+		return nil, fmt.Errorf("pdf: sampled function BitsPerSample %d is not 1, 2, 4, 8, 12, 16, 24 or 32", f.BitsPerSample)
+	}
+	if len(f.Samples) != count {
+		return nil, fmt.Errorf("pdf: sampled function needs %d samples, got %d", count, len(f.Samples))
+	}
+	if f.Encode != nil && len(f.Encode) != f.inputs() {
+		return nil, fmt.Errorf("pdf: sampled function encode (%d) must match inputs (%d)", len(f.Encode), f.inputs())
+	}
+	if f.Decode != nil && len(f.Decode) != 2*outputs {
+		return nil, fmt.Errorf("pdf: sampled function needs %d Decode values, got %d", 2*outputs, len(f.Decode))
+	}
+
+	obj := pw.NewObject()
+	size := make([]string, len(f.Size))
+	for i, s := range f.Size {
+		size[i] = strconv.Itoa(s)
+	}
+	obj.Dictionary = Dict{
+		"FunctionType":  "0",
+		"Domain":        numberArray(f.domain()),
+		"Range":         numberArray(f.Range),
+		"Size":          "[" + strings.Join(size, " ") + "]",
+		"BitsPerSample": strconv.Itoa(f.BitsPerSample),
+	}
+	if f.Encode != nil {
+		encode := make([]float64, 0, 2*len(f.Encode))
+		for _, e := range f.Encode {
+			encode = append(encode, e[0], e[1])
+		}
+		obj.Dictionary["Encode"] = numberArray(encode)
+	}
+	if f.Decode != nil {
+		obj.Dictionary["Decode"] = numberArray(f.Decode)
+	}
+
+	// only the end of the stream is padded to a full byte.
+	bw := bitWriter{w: obj.Data}
+	for _, s := range f.Samples {
+		if uint64(s) >= 1<<f.BitsPerSample {
+			return nil, fmt.Errorf("pdf: sample %d does not fit in %d bits", s, f.BitsPerSample)
+		}
+		bw.write(uint64(s), f.BitsPerSample)
+	}
+	bw.align()
+	obj.ForceStream = true
+	obj.SetCompression(9)
+	if err := obj.Save(); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// writeCalculatorFunction writes a type 4 function with the program as the
+// stream.
+func (pw *PDF) writeCalculatorFunction(f Function) (*Object, error) {
+	if _, err := f.outputs(); err != nil {
+		return nil, err
+	}
+	code, err := checkCalculatorCode(f.Code)
+	if err != nil {
+		return nil, err
+	}
+	obj := pw.NewObject()
+	obj.Dictionary = Dict{
+		"FunctionType": "4",
+		"Domain":       numberArray(f.domain()),
+		"Range":        numberArray(f.Range),
+	}
+	obj.Data.WriteString(code)
+	obj.ForceStream = true
+	if err := obj.Save(); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// checkCalculatorCode checks the syntax of a PostScript calculator program
+// and returns it with normalised white space.
+func checkCalculatorCode(code string) (string, error) {
+	tokens := strings.Fields(strings.NewReplacer("{", " { ", "}", " } ").Replace(code))
+	if len(tokens) == 0 || tokens[0] != "{" {
+		return "", fmt.Errorf("pdf: calculator function must be enclosed in braces")
+	}
+	end, err := checkCalculatorProc(tokens, 1)
+	if err != nil {
+		return "", err
+	}
+	if end != len(tokens) {
+		return "", fmt.Errorf("pdf: calculator function has %q after the closing brace", strings.Join(tokens[end:], " "))
+	}
+	return strings.Join(tokens, " "), nil
+}
+
+// checkCalculatorProc checks the procedure body starting at tokens[pos]
+// and returns the position after its closing brace.
+func checkCalculatorProc(tokens []string, pos int) (int, error) {
+	procs := 0
+	for pos < len(tokens) {
+		tok := tokens[pos]
+		pos++
+		switch {
+		case tok == "}":
+			if procs > 0 {
+				return 0, fmt.Errorf("pdf: calculator function procedure without if or ifelse")
+			}
+			return pos, nil
+		case tok == "{":
+			var err error
+			if pos, err = checkCalculatorProc(tokens, pos); err != nil {
+				return 0, err
+			}
+			procs++
+			continue
+		case tok == "if" || tok == "ifelse":
+			want := 1
+			if tok == "ifelse" {
+				want = 2
+			}
+			if procs != want {
+				return 0, fmt.Errorf("pdf: calculator function %s needs %d procedures, got %d", tok, want, procs)
+			}
+			procs = 0
+			continue
+		case slices.Contains(calculatorOperators, tok):
+		default:
+			// ParseFloat also accepts words such as Inf and NaN.
+			isNumber := strings.Trim(tok, "+-.0123456789eE") == ""
+			if _, err := strconv.ParseFloat(tok, 64); err != nil || !isNumber {
+				return 0, fmt.Errorf("pdf: unknown calculator function operator %q", tok)
+			}
+		}
+		if procs > 0 {
+			return 0, fmt.Errorf("pdf: calculator function procedure without if or ifelse")
+		}
+	}
+	return 0, fmt.Errorf("pdf: calculator function has a missing closing brace")
+}
@@ -33,12 +33,13 @@ type Imagefile struct {
 	// pendingDictEntries are extra Form XObject dictionary entries to apply
 	// at finishPDF time (PutFormXobjects). Currently used for /StructParent
 	// when the Imagefile participates in a tagged PDF as an atomic Figure
-	// content (PDF/UA-1 §7.1 Note 1).
+	// content (PDF/UA-1 §7.1 Note 1) and for the /OC of layered images.
 	pendingDictEntries map[string]string
 	Format             string
 	Filename           string
 	Box                string
 	colorspace         string
+	iccColorSpace      *ColorSpace // replaces the device colour space of bitmaps
 	bitsPerComponent   string
 	trns               []byte
 	smask              []byte
@@ -80,6 +81,37 @@ func (imgf *Imagefile) SetStructParent(idx int) {
 	imgf.pendingDictEntries["StructParent"] = fmt.Sprintf("%d", idx)
 }
 
+// SetOptionalContent makes the image visible only if the optional content
+// group or membership dictionary is visible, wherever the image is placed.
+func (imgf *Imagefile) SetOptionalContent(oc OptionalContent) {
+	if imgf.pendingDictEntries == nil {
+		imgf.pendingDictEntries = make(map[string]string)
+	}
+	imgf.pendingDictEntries["OC"] = oc.ocObjectnumber().Ref()
+}
+
+// SetColorSpace assigns a device-independent colour space to a bitmap image,
+// for example the ICC profile of a photo. The colour space must have as many
+// components as the image; the base of a palette image has three.
+func (imgf *Imagefile) SetColorSpace(cs *ColorSpace) error {
+	var n int
+	switch imgf.colorspace {
+	case "DeviceGray":
+		n = 1
+	case "DeviceRGB", "Indexed":
+		n = 3
+	case "DeviceCMYK":
+		n = 4
+	default:
+		return fmt.Errorf("pdf: cannot set the colour space of image %s", imgf.Filename)
+	}
+	if cs.components != n {
+		return fmt.Errorf("pdf: image %s needs a colour space with %d components, got %d", imgf.Filename, n, cs.components)
+	}
+	imgf.iccColorSpace = cs
+	return nil
+}
+
 // LoadImageFileWithBox loads an image from the disc with the given box and page
 // number. If box is empty, it defaults to /MediaBox.
 func (pw *PDF) LoadImageFileWithBox(filename string, box string, pagenumber int) (*Imagefile, error) {
@@ -390,6 +422,11 @@ func finishBitmap(imgf *Imagefile) error {
 		"Width":            fmt.Sprintf("%d", imgf.W),
 		"Height":           fmt.Sprintf("%d", imgf.H),
 	}
+	base := "/DeviceRGB"
+	if imgf.iccColorSpace != nil {
+		d["ColorSpace"] = string(imgf.iccColorSpace.Ref())
+		base = string(imgf.iccColorSpace.Ref())
+	}
 
 	if imgf.colorspace == "DeviceCMYK" {
 		d["Decode"] = "[1 0 1 0 1 0 1 0]"
@@ -417,11 +454,14 @@ func finishBitmap(imgf *Imagefile) error {
 		if err := palObj.Save(); err != nil {
 			return err
 		}
-		d["ColorSpace"] = fmt.Sprintf("[/Indexed /DeviceRGB %d %s]", size, palObj.ObjectNumber.Ref())
+		d["ColorSpace"] = fmt.Sprintf("[/Indexed %s %d %s]", base, size, palObj.ObjectNumber.Ref())
 	}
 	if imgf.decodeParms != nil {
 		d["DecodeParms"] = imgf.decodeParms
 	}
+	for k, v := range imgf.pendingDictEntries {
+		d[Name(k)] = v
+	}
 	imgo := imgf.imageobject
 
 	imgo.Dict(d)
@@ -0,0 +1,218 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// MeshVertex is a vertex of a triangle mesh shading (types 4 and 5). Color
+// has one value per colour component, or the single parameter t if the
+// shading has a function.
+type MeshVertex struct {
+	// Flag is only used by type 4 meshes: 0 starts a new triangle with this
+	// and the next two vertices, 1 forms a triangle with the last two
+	// vertices of the previous triangle (vb, vc) and 2 with the vertices
+	// va and vc.
+	Flag  int
+	X, Y  float64
+	Color []float64
+}
+
+// MeshPatch is a patch of a Coons (type 6) or tensor-product (type 7)
+// patch mesh shading.
+type MeshPatch struct {
+	// Flag 0 starts a new patch, 1, 2 and 3 share the second, third or
+	// fourth edge of the previous patch, whose points and colours are then
+	// omitted.
+	Flag int
+	// Points are the control points in the order of ISO 32000-1, Section
+	// 8.7.4.5.7: 12 for a Coons patch and 16 for a tensor-product patch
+	// with Flag 0, 4 fewer otherwise.
+	Points [][2]float64
+	// Colors are the colours of the corners, 4 with Flag 0 and 2 otherwise.
+	Colors [][]float64
+}
+
+// isZero reports whether the function is not set.
+func (f Function) isZero() bool {
+	return f.FunctionType == 0 && f.Domain == nil && f.Range == nil && f.Size == nil &&
+		f.BitsPerSample == 0 && f.Samples == nil
+}
+
+// meshPacker quantizes coordinates and colours of a mesh shading.
+type meshPacker struct {
+	bw         bitWriter
+	decode     []float64
+	coordBits  int
+	compBits   int
+	components int
+}
+
+// value writes v, which must lie in the range decode[i], decode[i+1].
+func (mp *meshPacker) value(v float64, i, bits int) error {
+	lo, hi := mp.decode[i], mp.decode[i+1]
+	if v < min(lo, hi) || v > max(lo, hi) {
+		return fmt.Errorf("pdf: mesh shading value %s is outside the Decode range [%s %s]", fmtPDFFloat(v), fmtPDFFloat(lo), fmtPDFFloat(hi))
+	}
+	var q uint64
+	if hi != lo {
+		q = uint64(math.Round((v - lo) / (hi - lo) * float64(uint64(1)<<bits-1)))
+	}
+	mp.bw.write(q, bits)
+	return nil
+}
+
+func (mp *meshPacker) point(x, y float64) error {
+	if err := mp.value(x, 0, mp.coordBits); err != nil {
+		return err
+	}
+	return mp.value(y, 2, mp.coordBits)
+}
+
+func (mp *meshPacker) color(c []float64) error {
+	if len(c) != mp.components {
+		return fmt.Errorf("pdf: mesh shading colour has %d components, want %d", len(c), mp.components)
+	}
+	for i, v := range c {
+		if err := mp.value(v, 4+2*i, mp.compBits); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// meshData adds the mesh entries to the shading dictionary and returns the
+// packed vertex or patch data of the stream.
+func (s Shading) meshData(d Dict, components int, hasFunction bool) ([]byte, error) {
+	switch s.BitsPerCoordinate {
+	case 1, 2, 4, 8, 12, 16, 24, 32:
+	default:
+		return nil, fmt.Errorf("pdf: mesh shading BitsPerCoordinate %d is not 1, 2, 4, 8, 12, 16, 24 or 32", s.BitsPerCoordinate)
+	}
+	switch s.BitsPerComponent {
+	case 1, 2, 4, 8, 12, 16:
+	default:
+		return nil, fmt.Errorf("pdf: mesh shading BitsPerComponent %d is not 1, 2, 4, 8, 12 or 16", s.BitsPerComponent)
+	}
+	if s.ShadingType != 5 {
+		switch s.BitsPerFlag {
+		case 2, 4, 8:
+		default:
+			return nil, fmt.Errorf("pdf: mesh shading BitsPerFlag %d is not 2, 4 or 8", s.BitsPerFlag)
+		}
+	}
+	if hasFunction {
+		components = 1
+	} else if components == 0 {
+		components = len(s.Decode)/2 - 2
+	}
+	if components < 1 || len(s.Decode) != 4+2*components {
+		return nil, fmt.Errorf("pdf: mesh shading Decode needs %d values, got %d", 4+2*max(components, 1), len(s.Decode))
+	}
+
+	d["BitsPerCoordinate"] = strconv.Itoa(s.BitsPerCoordinate)
+	d["BitsPerComponent"] = strconv.Itoa(s.BitsPerComponent)
+	d["Decode"] = numberArray(s.Decode)
+	if s.ShadingType != 5 {
+		d["BitsPerFlag"] = strconv.Itoa(s.BitsPerFlag)
+	}
+
+	var buf bytes.Buffer
+	mp := &meshPacker{
+		bw:         bitWriter{w: &buf},
+		decode:     s.Decode,
+		coordBits:  s.BitsPerCoordinate,
+		compBits:   s.BitsPerComponent,
+		components: components,
+	}
+	switch s.ShadingType {
+	case 4:
+		if len(s.Vertices) < 3 {
+			return nil, fmt.Errorf("pdf: free-form mesh shading needs at least 3 vertices")
+		}
+		// a vertex with flag 0 takes the next two vertices along
+		for i := 0; i < len(s.Vertices); {
+			switch s.Vertices[i].Flag {
+			case 0:
+				if i+3 > len(s.Vertices) {
+					return nil, fmt.Errorf("pdf: free-form mesh shading vertex %d with flag 0 needs two more vertices", i)
+				}
+				i += 3
+			case 1, 2:
+				if i == 0 {
+					return nil, fmt.Errorf("pdf: free-form mesh shading must start with flag 0")
+				}
+				i++
+			default:
+				return nil, fmt.Errorf("pdf: free-form mesh shading vertex flag %d is not 0, 1 or 2", s.Vertices[i].Flag)
+			}
+		}
+		for _, v := range s.Vertices {
+			// each vertex starts at a byte boundary
+			mp.bw.write(uint64(v.Flag), s.BitsPerFlag)
+			if err := mp.point(v.X, v.Y); err != nil {
+				return nil, err
+			}
+			if err := mp.color(v.Color); err != nil {
+				return nil, err
+			}
+			mp.bw.align()
+		}
+	case 5:
+		if s.VerticesPerRow < 2 {
+			return nil, fmt.Errorf("pdf: lattice mesh shading needs at least 2 vertices per row, got %d", s.VerticesPerRow)
+		}
+		if len(s.Vertices) < 2*s.VerticesPerRow || len(s.Vertices)%s.VerticesPerRow != 0 {
+			return nil, fmt.Errorf("pdf: lattice mesh shading needs at least two full rows of %d vertices, got %d vertices", s.VerticesPerRow, len(s.Vertices))
+		}
+		d["VerticesPerRow"] = strconv.Itoa(s.VerticesPerRow)
+		for _, v := range s.Vertices {
+			if err := mp.point(v.X, v.Y); err != nil {
+				return nil, err
+			}
+			if err := mp.color(v.Color); err != nil {
+				return nil, err
+			}
+		}
+		mp.bw.align()
+	case 6, 7:
+		if len(s.Patches) == 0 {
+			return nil, fmt.Errorf("pdf: patch mesh shading needs at least one patch")
+		}
+		points := 12
+		if s.ShadingType == 7 {
+			points = 16
+		}
+		for i, p := range s.Patches {
+			wantPoints, wantColors := points, 4
+			switch {
+			case p.Flag == 0:
+			case p.Flag < 0 || p.Flag > 3:
+				return nil, fmt.Errorf("pdf: patch mesh shading flag %d is not between 0 and 3", p.Flag)
+			case i == 0:
+				return nil, fmt.Errorf("pdf: patch mesh shading must start with flag 0")
+			default:
+				wantPoints, wantColors = points-4, 2
+			}
+			if len(p.Points) != wantPoints || len(p.Colors) != wantColors {
+				return nil, fmt.Errorf("pdf: patch %d with flag %d needs %d points and %d colours, got %d and %d",
+					i, p.Flag, wantPoints, wantColors, len(p.Points), len(p.Colors))
+			}
+			mp.bw.write(uint64(p.Flag), s.BitsPerFlag)
+			for _, pt := range p.Points {
+				if err := mp.point(pt[0], pt[1]); err != nil {
+					return nil, err
+				}
+			}
+			for _, c := range p.Colors {
+				if err := mp.color(c); err != nil {
+					return nil, err
+				}
+			}
+			mp.bw.align()
+		}
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,207 @@
+package pdf
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestMeshShadingFreeForm(t *testing.T) {
+	pw, buf := newTestPDF()
+	sh, err := pw.WriteShading(Shading{
+		ShadingType:       4,
+		ColorSpace:        "/DeviceRGB",
+		BitsPerCoordinate: 8,
+		BitsPerComponent:  8,
+		BitsPerFlag:       8,
+		Decode:            []float64{0, 255, 0, 255, 0, 1, 0, 1, 0, 1},
+		Vertices: []MeshVertex{
+			{X: 0, Y: 0, Color: []float64{1, 0, 0}},
+			{X: 100, Y: 0, Color: []float64{0, 1, 0}},
+			{X: 50, Y: 100, Color: []float64{0, 0, 1}},
+			{Flag: 1, X: 150, Y: 100, Color: []float64{1, 1, 1}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("WriteShading: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"/ShadingType 4",
+		"/BitsPerCoordinate 8",
+		"/BitsPerComponent 8",
+		"/BitsPerFlag 8",
+		"/Decode [0 255 0 255 0 1 0 1 0 1]",
+		"/Filter /FlateDecode",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q\nfull output:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "/Function") || strings.Contains(out, "/Coords") {
+		t.Errorf("mesh shading without function has /Function or /Coords\nfull output:\n%s", out)
+	}
+	want := []byte{
+		0, 0, 0, 255, 0, 0,
+		0, 100, 0, 0, 255, 0,
+		0, 50, 100, 0, 0, 255,
+		1, 150, 100, 255, 255, 255,
+	}
+	if _, got := streamData(t, buf.Bytes(), int(sh.ObjectNumber)); !bytes.Equal(got, want) {
+		t.Errorf("data = % x, want % x", got, want)
+	}
+}
+
+func TestMeshShadingLatticeFunction(t *testing.T) {
+	pw, buf := newTestPDF()
+	_, err := pw.WriteShading(Shading{
+		ShadingType:       5,
+		ColorSpace:        "/DeviceGray",
+		Function:          Function{FunctionType: 2, N: 1},
+		BitsPerCoordinate: 4,
+		BitsPerComponent:  4,
+		Decode:            []float64{0, 15, 0, 15, 0, 1},
+		VerticesPerRow:    2,
+		Vertices: []MeshVertex{
+			{X: 0, Y: 0, Color: []float64{0}},
+			{X: 15, Y: 0, Color: []float64{1}},
+			{X: 0, Y: 15, Color: []float64{1}},
+		},
+	})
+	if err == nil || !strings.Contains(err.Error(), "two full rows") {
+		t.Fatalf("expected a row error, got %v", err)
+	}
+	pw, buf = newTestPDF()
+	sh, err := pw.WriteShading(Shading{
+		ShadingType:       5,
+		ColorSpace:        "/DeviceGray",
+		Function:          Function{FunctionType: 2, N: 1},
+		BitsPerCoordinate: 4,
+		BitsPerComponent:  4,
+		Decode:            []float64{0, 15, 0, 15, 0, 1},
+		VerticesPerRow:    2,
+		Vertices: []MeshVertex{
+			{X: 0, Y: 0, Color: []float64{0}},
+			{X: 15, Y: 0, Color: []float64{1}},
+			{X: 0, Y: 15, Color: []float64{1}},
+			{X: 15, Y: 15, Color: []float64{0}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("WriteShading: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"/ShadingType 5",
+		"/VerticesPerRow 2",
+		"/Function 1 0 R",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q\nfull output:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "/BitsPerFlag") {
+		t.Error("lattice mesh shading must not have /BitsPerFlag")
+	}
+	// 12 bits per vertex, no padding between vertices
+	want := []byte{0x00, 0x0f, 0x0f, 0x0f, 0xff, 0xf0}
+	if _, got := streamData(t, buf.Bytes(), int(sh.ObjectNumber)); !bytes.Equal(got, want) {
+		t.Errorf("data = % x, want % x", got, want)
+	}
+}
+
+func TestMeshShadingCoonsPattern(t *testing.T) {
+	pw, buf := newTestPDF()
+	first := MeshPatch{
+		Points: [][2]float64{
+			{0, 0}, {0, 1}, {0, 2}, {0, 3}, {1, 3}, {2, 3},
+			{3, 3}, {3, 2}, {3, 1}, {3, 0}, {2, 0}, {1, 0},
+		},
+		Colors: [][]float64{{0}, {1}, {1}, {0}},
+	}
+	second := MeshPatch{
+		Flag:   2,
+		Points: [][2]float64{{4, 3}, {5, 3}, {6, 3}, {6, 2}, {6, 1}, {6, 0}, {5, 0}, {4, 0}},
+		Colors: [][]float64{{1}, {0}},
+	}
+	_, err := pw.WriteShadingPattern(ShadingPattern{
+		Shading: Shading{
+			ShadingType:       6,
+			ColorSpace:        "/DeviceGray",
+			BitsPerCoordinate: 4,
+			BitsPerComponent:  1,
+			BitsPerFlag:       2,
+			Decode:            []float64{0, 15, 0, 15, 0, 1},
+			Patches:           []MeshPatch{first, second},
+		},
+		Matrix: [6]float64{1, 0, 0, 1, 0, 0},
+	})
+	if err != nil {
+		t.Fatalf("WriteShadingPattern: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"/ShadingType 6", "/PatternType 2", "/Shading 1 0 R"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q\nfull output:\n%s", want, out)
+		}
+	}
+	// 2 flag bits + 12 points * 8 bits + 4 colour bits = 102 bits = 13 bytes,
+	// 2 + 8*8 + 2 = 68 bits = 9 bytes for the second patch
+	if _, data := streamData(t, buf.Bytes(), 1); len(data) != 22 {
+		t.Errorf("data has %d bytes, want 22", len(data))
+	}
+}
+
+func TestMeshShadingErrors(t *testing.T) {
+	rgb := []float64{0, 0, 0}
+	triangle := []MeshVertex{{Color: rgb}, {Color: rgb}, {Color: rgb}}
+	base := Shading{
+		ShadingType:       4,
+		BitsPerCoordinate: 8,
+		BitsPerComponent:  8,
+		BitsPerFlag:       8,
+		Decode:            []float64{0, 100, 0, 100, 0, 1, 0, 1, 0, 1},
+		Vertices:          triangle,
+	}
+	tests := []struct {
+		name   string
+		modify func(s *Shading)
+		want   string
+	}{
+		{"coordinate bits", func(s *Shading) { s.BitsPerCoordinate = 3 }, "BitsPerCoordinate 3"},
+		{"component bits", func(s *Shading) { s.BitsPerComponent = 32 }, "BitsPerComponent 32"},
+		{"flag bits", func(s *Shading) { s.BitsPerFlag = 1 }, "BitsPerFlag 1"},
+		{"decode", func(s *Shading) { s.Decode = s.Decode[:6] }, "Decode needs 10 values"},
+		{"decode with function", func(s *Shading) { s.Function = Function{FunctionType: 2, C0: rgb, C1: rgb} }, "Decode needs 6 values"},
+		{"too few vertices", func(s *Shading) { s.Vertices = triangle[:2] }, "at least 3 vertices"},
+		{"first flag", func(s *Shading) { s.Vertices = []MeshVertex{{Flag: 1, Color: rgb}, {Color: rgb}, {Color: rgb}} }, "start with flag 0"},
+		{"incomplete triangle", func(s *Shading) { s.Vertices = append(triangle, MeshVertex{Color: rgb}) }, "needs two more vertices"},
+		{"flag value", func(s *Shading) { s.Vertices = append(triangle, MeshVertex{Flag: 3, Color: rgb}) }, "flag 3"},
+		{"outside decode", func(s *Shading) { s.Vertices = []MeshVertex{{X: 200, Color: rgb}, {Color: rgb}, {Color: rgb}} }, "outside the Decode range"},
+		{"colour components", func(s *Shading) { s.Vertices = []MeshVertex{{Color: []float64{0}}, {Color: rgb}, {Color: rgb}} }, "has 1 components"},
+		{"lattice row", func(s *Shading) { s.ShadingType = 5; s.VerticesPerRow = 1 }, "at least 2 vertices per row"},
+		{"no patches", func(s *Shading) { s.ShadingType = 6 }, "at least one patch"},
+		{"patch points", func(s *Shading) {
+			s.ShadingType = 7
+			s.Patches = []MeshPatch{{Points: make([][2]float64, 12), Colors: [][]float64{rgb, rgb, rgb, rgb}}}
+		}, "needs 16 points and 4 colours"},
+		{"patch first flag", func(s *Shading) {
+			s.ShadingType = 6
+			s.Patches = []MeshPatch{{Flag: 1, Points: make([][2]float64, 8), Colors: [][]float64{rgb, rgb}}}
+		}, "start with flag 0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pw, _ := newTestPDF()
+			s := base
+			tt.modify(&s)
+			_, err := pw.WriteShading(s)
+			if err == nil {
+				t.Fatalf("expected error containing %q, got nil", tt.want)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error %q does not contain %q", err.Error(), tt.want)
+			}
+		})
+	}
+}
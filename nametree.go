@@ -0,0 +1,156 @@
+package pdf
+
+import (
+	"bytes"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// treeNodeSize is the maximum number of entries in a leaf of a name or number
+// tree and the maximum number of kids of an intermediate node.
+const treeNodeSize = 64
+
+// treeEntry is a key/value pair of a name or number tree, both already
+// serialized.
+type treeEntry struct {
+	key   string
+	value string
+}
+
+// treeNode is a written node of a tree with the keys of its first and last
+// entry.
+type treeNode struct {
+	objnum      Objectnumber
+	first, last string
+}
+
+// WriteNameTree writes a balanced name tree and returns the object number of
+// its root. Trees with more than a few dozen entries are split into leaves
+// with /Limits below intermediate /Kids nodes, so that viewers need not load
+// one huge array.
+func (pw *PDF) WriteNameTree(names NameTreeData) (Objectnumber, error) {
+	keys := names.sortedKeys()
+	entries := make([]treeEntry, len(keys))
+	for i, k := range keys {
+		entries[i] = treeEntry{key: Serialize(k), value: names[k].Ref()}
+	}
+	return pw.writeTree("Names", entries)
+}
+
+// sortedKeys returns the keys of the name tree in the order required by the
+// PDF specification: by the bytes of the encoded strings, not by the Go
+// strings.
+func (names NameTreeData) sortedKeys() []String {
+	keys := make([]String, 0, len(names))
+	for k := range names {
+		keys = append(keys, k)
+	}
+	slices.SortFunc(keys, func(a, b String) int {
+		return bytes.Compare(pdfStringBytes(string(a)), pdfStringBytes(string(b)))
+	})
+	return keys
+}
+
+// WriteNumberTree writes a balanced number tree and returns the object number
+// of its root. The values are serialized with Serialize.
+func (pw *PDF) WriteNumberTree(nums map[int]any) (Objectnumber, error) {
+	keys := make([]int, 0, len(nums))
+	for k := range nums {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	entries := make([]treeEntry, len(keys))
+	for i, k := range keys {
+		entries[i] = treeEntry{key: strconv.Itoa(k), value: Serialize(nums[k])}
+	}
+	return pw.writeTree("Nums", entries)
+}
+
+// writeTree writes the leaves with the entries (kind is Names or Nums) and the
+// intermediate nodes bottom up. The root has no /Limits.
+func (pw *PDF) writeTree(kind Name, entries []treeEntry) (Objectnumber, error) {
+	if len(entries) <= treeNodeSize {
+		root := pw.NewObject()
+		root.Dict(Dict{kind: treeArray(entries)})
+		return root.ObjectNumber, root.Save()
+	}
+	var level []treeNode
+	for chunk := range slices.Chunk(entries, treeNodeSize) {
+		first, last := chunk[0].key, chunk[len(chunk)-1].key
+		leaf := pw.NewObject()
+		leaf.Dict(Dict{
+			kind:     treeArray(chunk),
+			"Limits": "[" + first + " " + last + "]",
+		})
+		if err := leaf.Save(); err != nil {
+			return 0, err
+		}
+		level = append(level, treeNode{objnum: leaf.ObjectNumber, first: first, last: last})
+	}
+	for {
+		root := len(level) <= treeNodeSize
+		var next []treeNode
+		for chunk := range slices.Chunk(level, treeNodeSize) {
+			kids := make([]string, len(chunk))
+			for i, n := range chunk {
+				kids[i] = n.objnum.Ref()
+			}
+			first, last := chunk[0].first, chunk[len(chunk)-1].last
+			node := pw.NewObject()
+			node.Dict(Dict{"Kids": "[" + strings.Join(kids, " ") + "]"})
+			if !root {
+				node.Dictionary["Limits"] = "[" + first + " " + last + "]"
+			}
+			if err := node.Save(); err != nil {
+				return 0, err
+			}
+			next = append(next, treeNode{objnum: node.ObjectNumber, first: first, last: last})
+		}
+		if root {
+			return next[0].objnum, nil
+		}
+		level = next
+	}
+}
+
+// treeArray returns the key/value array of a leaf.
+func treeArray(entries []treeEntry) string {
+	var b strings.Builder
+	b.WriteString("[")
+	for i, e := range entries {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(e.key + " " + e.value)
+	}
+	b.WriteString("]")
+	return b.String()
+}
+
+// writeNameTrees replaces the flat NameTreeData arrays in the /Names
+// dictionary of the catalog, such as /EmbeddedFiles or /JavaScript, by
+// references to balanced name trees.
+func (pw *PDF) writeNameTrees() error {
+	keys := make([]Name, 0, len(pw.names))
+	for k := range pw.names {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		d, ok := pw.names[k].(Dict)
+		if !ok {
+			continue
+		}
+		data, ok := d["Names"].(NameTreeData)
+		if !ok || d["Kids"] != nil {
+			continue
+		}
+		root, err := pw.WriteNameTree(data)
+		if err != nil {
+			return err
+		}
+		pw.names[k] = root.Ref()
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package pdf
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestWriteNameTreeSingleLeaf(t *testing.T) {
+	pw, buf := newTestPDF()
+	root, err := pw.WriteNameTree(NameTreeData{"b": 2, "a": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, fmt.Sprintf("%d 0 obj\n<<\n /Names [(a) 1 0 R (b) 2 0 R]\n>>", root)) {
+		t.Errorf("unexpected tree:\n%s", out)
+	}
+	if strings.Contains(out, "/Limits") {
+		t.Error("the root must not have /Limits")
+	}
+}
+
+func TestWriteNameTreeBalanced(t *testing.T) {
+	pw, buf := newTestPDF()
+	names := make(NameTreeData)
+	n := treeNodeSize*treeNodeSize + 1
+	for i := range n {
+		names[String(fmt.Sprintf("dest%05d", i))] = Objectnumber(i + 1)
+	}
+	root, err := pw.WriteNameTree(names)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	// 65 leaves, two intermediate nodes and the root
+	if got := strings.Count(out, "/Names ["); got != treeNodeSize+1 {
+		t.Errorf("got %d leaves, want %d", got, treeNodeSize+1)
+	}
+	if got := strings.Count(out, "/Kids ["); got != 3 {
+		t.Errorf("got %d intermediate nodes, want 3", got)
+	}
+	if !strings.Contains(out, "/Limits [(dest00000) (dest00063)]") {
+		t.Error("first leaf has wrong /Limits")
+	}
+	if !strings.Contains(out, "/Limits [(dest04096) (dest04096)]") {
+		t.Error("last leaf has wrong /Limits")
+	}
+	if !strings.Contains(out, "/Limits [(dest00000) (dest04095)]") {
+		t.Error("first intermediate node has wrong /Limits")
+	}
+	rootObj := regexp.MustCompile(fmt.Sprintf(`(?s)\n%d 0 obj\n<<(.*?)>>`, root)).FindStringSubmatch(out)
+	if rootObj == nil || strings.Contains(rootObj[1], "/Limits") || !strings.Contains(rootObj[1], "/Kids") {
+		t.Errorf("unexpected root node %q", rootObj)
+	}
+}
+
+func TestWriteNameTreeEncodedOrder(t *testing.T) {
+	pw, buf := newTestPDF()
+	names := make(NameTreeData)
+	// Go string order is é < Ω < €, the encoded order is € (0xA0) < é
+	// (0xE9) < Ω (UTF-16BE, 0xFE 0xFF ...)
+	for i := range 40 {
+		for _, prefix := range []string{"é", "Ω", "€"} {
+			names[String(fmt.Sprintf("%s%02d", prefix, i))] = Objectnumber(i + 1)
+		}
+	}
+	if _, err := pw.WriteNameTree(names); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, limits := range [][2]string{{"€00", "é23"}, {"é24", "Ω39"}} {
+		want := "/Limits [" + stringToPDF(limits[0]) + " " + stringToPDF(limits[1]) + "]"
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q", want)
+		}
+	}
+}
+
+func TestWriteNumberTree(t *testing.T) {
+	pw, buf := newTestPDF()
+	nums := make(map[int]any)
+	for i := range treeNodeSize + 1 {
+		nums[i*2] = Objectnumber(i + 1)
+	}
+	nums[1] = "[1 0 R 2 0 R]"
+	if _, err := pw.WriteNumberTree(nums); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"/Nums [0 1 0 R 1 [1 0 R 2 0 R] 2 2 0 R",
+		"/Limits [0 124]",
+		"/Limits [126 128]",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q", want)
+		}
+	}
+}
+
+func TestDestsNameTree(t *testing.T) {
+	pw, buf := newTestPDF()
+	page := pw.AddPage(pw.NewObject(), 0)
+	for i := range 100 {
+		name := String(fmt.Sprintf("anchor%03d", i))
+		pw.NameDestinations[name] = &NameDest{Name: name, PageObjectnumber: page.Objnum}
+	}
+	js := pw.GetCatalogNameTreeDict("JavaScript")
+	js["Names"] = NameTreeData{"init": 1}
+	if err := pw.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"/Limits [(anchor000) (anchor063)]",
+		"/Limits [(anchor064) (anchor099)]",
+		"/Names [(init) 1 0 R]",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q", want)
+		}
+	}
+	if !regexp.MustCompile(`/Dests \d+ 0 R`).MatchString(out) || !regexp.MustCompile(`/JavaScript \d+ 0 R`).MatchString(out) {
+		t.Error("catalog /Names must reference the tree roots")
+	}
+}
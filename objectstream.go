@@ -0,0 +1,172 @@
+package pdf
+
+import (
+	"bytes"
+	"crypto/md5"
+	"fmt"
+	"strconv"
+)
+
+// objectStreamCapacity is the maximum number of objects packed into a single
+// /ObjStm stream. Larger streams compress better, but a reader has to inflate
+// the whole stream to get at a single object.
+const objectStreamCapacity = 200
+
+// compressedLocation records where an object lives inside an object stream:
+// the number of the /ObjStm stream and the index of the object within it.
+type compressedLocation struct {
+	stream Objectnumber
+	index  int
+}
+
+// objectStream collects serialized non-stream objects until they are written
+// as a single compressed /ObjStm stream (PDF 1.7 §7.5.7).
+type objectStream struct {
+	objects []Objectnumber
+	offsets []int
+	data    bytes.Buffer
+}
+
+// compressible reports whether the object may be placed in an object stream.
+// Streams (including raw imported streams) and the /Encrypt dictionary must
+// be written as top-level objects.
+func (obj *Object) compressible() bool {
+	if obj.unencrypted {
+		return false
+	}
+	if obj.Raw {
+		return !bytes.HasSuffix(bytes.TrimRight(obj.Data.Bytes(), " \r\n"), []byte("endstream"))
+	}
+	return obj.Data.Len() == 0 && !obj.ForceStream
+}
+
+// addToObjectStream appends the serialized body of an object to the current
+// object stream and flushes the stream once it is full.
+func (pw *PDF) addToObjectStream(onum Objectnumber, body []byte) error {
+	if pw.objStm == nil {
+		pw.objStm = &objectStream{}
+	}
+	pending := pw.objStm
+	if len(body) == 0 {
+		body = []byte("null")
+	}
+	pending.objects = append(pending.objects, onum)
+	pending.offsets = append(pending.offsets, pending.data.Len())
+	pending.data.Write(body)
+	pending.data.WriteByte('\n')
+	if len(pending.objects) >= objectStreamCapacity {
+		return pw.flushObjectStream()
+	}
+	return nil
+}
+
+// flushObjectStream writes the pending objects as one /ObjStm stream and
+// records their compressed locations for the cross-reference stream.
+func (pw *PDF) flushObjectStream() error {
+	pending := pw.objStm
+	if pending == nil || len(pending.objects) == 0 {
+		return nil
+	}
+	pw.objStm = nil
+
+	var header bytes.Buffer
+	for i, onum := range pending.objects {
+		writeInt(&header, int(onum))
+		header.WriteByte(' ')
+		writeInt(&header, pending.offsets[i])
+		header.WriteByte(' ')
+	}
+	header.WriteByte('\n')
+
+	stm := pw.NewObject()
+	stm.Dictionary = Dict{
+		"Type":  "/ObjStm",
+		"N":     strconv.Itoa(len(pending.objects)),
+		"First": strconv.Itoa(header.Len()),
+	}
+	stm.Data.Write(header.Bytes())
+	stm.Data.Write(pending.data.Bytes())
+	stm.SetCompression(9)
+	for i, onum := range pending.objects {
+		pw.compressedLocations[onum] = compressedLocation{stream: stm.ObjectNumber, index: i}
+	}
+	return stm.Save()
+}
+
+// bytesNeeded returns the number of bytes required to store v big-endian.
+func bytesNeeded(v int64) int {
+	n := 1
+	for v > 0xff {
+		v >>= 8
+		n++
+	}
+	return n
+}
+
+// writeXRefStream writes a cross-reference stream (PDF 1.7 §7.5.8) that
+// replaces both the classic xref table and the trailer dictionary. The
+// entries of trailer are copied into the stream dictionary.
+func (pw *PDF) writeXRefStream(trailer Dict) error {
+	if err := pw.flushObjectStream(); err != nil {
+		return err
+	}
+	if err := pw.ensureHeader(); err != nil {
+		return err
+	}
+	xref := pw.NewObject()
+	size := pw.nextobject
+	// The xref stream must list itself. startObject places the object at
+	// pos+1, so its offset is known before the stream data is built.
+	xrefpos := pw.pos + 1
+	pw.objectlocations[xref.ObjectNumber] = xrefpos
+
+	var maxField2 int64 = 0xffff
+	for _, loc := range pw.objectlocations {
+		maxField2 = max(maxField2, loc)
+	}
+	for _, cl := range pw.compressedLocations {
+		maxField2 = max(maxField2, int64(cl.stream))
+	}
+	w2 := bytesNeeded(maxField2)
+
+	var data bytes.Buffer
+	writeEntry := func(typ byte, f2 int64, f3 int) {
+		data.WriteByte(typ)
+		for i := w2 - 1; i >= 0; i-- {
+			data.WriteByte(byte(f2 >> (8 * i)))
+		}
+		data.WriteByte(byte(f3 >> 8))
+		data.WriteByte(byte(f3))
+	}
+	for i := Objectnumber(0); i < size; i++ {
+		if i == 0 {
+			writeEntry(0, 0, 0xffff)
+		} else if cl, ok := pw.compressedLocations[i]; ok {
+			writeEntry(2, int64(cl.stream), cl.index)
+		} else if loc, ok := pw.objectlocations[i]; ok {
+			writeEntry(1, loc, 0)
+		} else {
+			writeEntry(0, 0, 0)
+		}
+	}
+	sum := fmt.Sprintf("%X", md5.Sum(data.Bytes()))
+
+	xref.Dictionary = Dict{
+		"Type": "/XRef",
+		"Size": strconv.Itoa(int(size)),
+		"W":    fmt.Sprintf("[1 %d 2]", w2),
+	}
+	if trailer["ID"] == nil {
+		xref.Dictionary["ID"] = "[<" + sum + "> <" + sum + ">]"
+	}
+	for k, v := range trailer {
+		xref.Dictionary[k] = v
+	}
+	xref.Data = &data
+	xref.SetCompression(9)
+	xref.unencrypted = true
+	if err := xref.Save(); err != nil {
+		return err
+	}
+	return pw.Printf("\nstartxref\n%d\n%%%%EOF\n", xrefpos)
+}
@@ -0,0 +1,160 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// streamData returns the (inflated if needed) data of the stream object
+// onum found in the PDF bytes.
+func streamData(t *testing.T, pdf []byte, onum int) (string, []byte) {
+	t.Helper()
+	start := bytes.Index(pdf, fmt.Appendf(nil, "\n%d 0 obj\n", onum))
+	if start < 0 {
+		t.Fatalf("object %d not found", onum)
+	}
+	rest := pdf[start:]
+	end := bytes.Index(rest, []byte("endobj"))
+	obj := rest[:end]
+	si := bytes.Index(obj, []byte("\nstream\n"))
+	if si < 0 {
+		return string(obj), nil
+	}
+	dict := string(obj[:si])
+	data := obj[si+len("\nstream\n") : bytes.LastIndex(obj, []byte("\nendstream"))]
+	if strings.Contains(dict, "/FlateDecode") {
+		zr, err := zlib.NewReader(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("object %d: %v", onum, err)
+		}
+		data, err = io.ReadAll(zr)
+		if err != nil {
+			t.Fatalf("object %d: %v", onum, err)
+		}
+	}
+	return dict, data
+}
+
+func TestObjectStreams(t *testing.T) {
+	var buf bytes.Buffer
+	pw := NewPDFWriter(&buf)
+	pw.ObjectStreams = true
+	pw.DefaultPageWidth = 200
+	pw.DefaultPageHeight = 100
+	for range 3 {
+		content := pw.NewObject()
+		content.Data.WriteString("0 0 m 10 10 l S")
+		pg := pw.AddPage(content, 0)
+		pg.Annotations = append(pg.Annotations, Annotation{
+			Subtype: "Link",
+			Action:  "<< /S /URI /URI (https://example.com) >>",
+			Rect:    [4]float64{0, 0, 10, 10},
+		})
+	}
+	if err := pw.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.Bytes()
+	if bytes.Contains(out, []byte("\nxref\n")) || bytes.Contains(out, []byte("trailer")) {
+		t.Fatal("classic xref table written although ObjectStreams is set")
+	}
+	m := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindSubmatch(out)
+	if m == nil {
+		t.Fatal("startxref not found")
+	}
+	xrefpos, _ := strconv.Atoi(string(m[1]))
+	var xrefnum int
+	if _, err := fmt.Sscanf(string(out[xrefpos:]), "%d 0 obj", &xrefnum); err != nil {
+		t.Fatalf("startxref does not point to an object: %v", err)
+	}
+	dict, data := streamData(t, out, xrefnum)
+	if !strings.Contains(dict, "/Type /XRef") {
+		t.Fatalf("startxref object is not an xref stream:\n%s", dict)
+	}
+	var w2 int
+	wm := regexp.MustCompile(`/W \[1 (\d+) 2\]`).FindStringSubmatch(dict)
+	if wm == nil {
+		t.Fatalf("no /W in %s", dict)
+	}
+	w2, _ = strconv.Atoi(wm[1])
+	sm := regexp.MustCompile(`/Size (\d+)`).FindStringSubmatch(dict)
+	size, _ := strconv.Atoi(sm[1])
+	entryLen := 1 + w2 + 2
+	if len(data) != size*entryLen {
+		t.Fatalf("xref stream has %d bytes, want %d", len(data), size*entryLen)
+	}
+
+	compressed := 0
+	for i := 1; i < size; i++ {
+		e := data[i*entryLen : (i+1)*entryLen]
+		var f2 int
+		for _, b := range e[1 : 1+w2] {
+			f2 = f2<<8 | int(b)
+		}
+		f3 := int(e[1+w2])<<8 | int(e[2+w2])
+		switch e[0] {
+		case 1:
+			if !bytes.HasPrefix(out[f2:], fmt.Appendf(nil, "%d 0 obj", i)) {
+				t.Errorf("object %d: offset %d does not point to the object", i, f2)
+			}
+		case 2:
+			compressed++
+			stmDict, stmData := streamData(t, out, f2)
+			if !strings.Contains(stmDict, "/Type /ObjStm") {
+				t.Fatalf("object %d: container %d is not an object stream", i, f2)
+			}
+			fields := strings.Fields(string(stmData))
+			if fields[2*f3] != strconv.Itoa(i) {
+				t.Errorf("object %d: index %d in object stream %d holds object %s", i, f3, f2, fields[2*f3])
+			}
+		}
+	}
+	// three annotations, three pages, pages, catalog, info
+	if compressed < 9 {
+		t.Errorf("expected at least 9 compressed objects, got %d", compressed)
+	}
+}
+
+func TestObjectStreamsKeepStreamsTopLevel(t *testing.T) {
+	pw, buf := newTestPDF()
+	pw.ObjectStreams = true
+	stm := pw.NewObject()
+	stm.Data.WriteString("data")
+	raw := pw.NewObject()
+	raw.Raw = true
+	raw.Data = bytes.NewBufferString("<</Length 4>>\nstream\ndata\nendstream")
+	rawDict := pw.NewObject()
+	rawDict.Raw = true
+	rawDict.Data = bytes.NewBufferString("<</Type /ExtGState /CA 0.5 >>")
+	stm.comment = "top level"
+	rawDict.comment = "compressed"
+	for _, o := range []*Object{stm, raw, rawDict} {
+		if err := o.Save(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, ok := pw.objectlocations[stm.ObjectNumber]; !ok {
+		t.Error("stream object not written at top level")
+	}
+	if _, ok := pw.objectlocations[raw.ObjectNumber]; !ok {
+		t.Error("raw stream object not written at top level")
+	}
+	if _, ok := pw.objectlocations[rawDict.ObjectNumber]; ok {
+		t.Error("raw dictionary object should go into an object stream")
+	}
+	if !strings.Contains(buf.String(), "% top level") || strings.Contains(buf.String(), "% compressed") {
+		t.Error("comments must only be written for top level objects")
+	}
+	if err := pw.flushObjectStream(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := pw.compressedLocations[rawDict.ObjectNumber]; !ok {
+		t.Error("raw dictionary object not recorded as compressed")
+	}
+}
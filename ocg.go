@@ -0,0 +1,229 @@
+package pdf
+
+import (
+	"fmt"
+	"strings"
+)
+
+// OptionalContent is an optional content group or an optional content
+// membership dictionary. Content, images and annotations that belong to it
+// are only shown when it is visible.
+type OptionalContent interface {
+	ocObjectnumber() Objectnumber
+}
+
+// OptionalContentGroup is a layer which the user can show or hide in the
+// viewer. Create groups with PDF.NewOptionalContentGroup.
+type OptionalContentGroup struct {
+	Name string
+	// Off hides the group when the document is opened.
+	Off bool
+	// Locked prevents the user from changing the visibility of the group.
+	Locked bool
+	// PrintState and ViewState are ON or OFF to make the group visible or
+	// hidden when printing or viewing the document, regardless of its
+	// visibility in the viewer. Empty values leave the visibility as it is.
+	PrintState Name
+	ViewState  Name
+	// Objnum is the object number of the group, for example for a
+	// SetOCGState action.
+	Objnum Objectnumber
+}
+
+func (ocg *OptionalContentGroup) ocObjectnumber() Objectnumber {
+	return ocg.Objnum
+}
+
+// OptionalContentMembership makes content visible depending on the
+// visibility of several groups. Create it with
+// PDF.NewOptionalContentMembership.
+type OptionalContentMembership struct {
+	Groups []*OptionalContentGroup
+	// Policy is AnyOn (the default), AllOn, AnyOff or AllOff.
+	Policy Name
+	objnum Objectnumber
+}
+
+func (ocmd *OptionalContentMembership) ocObjectnumber() Objectnumber {
+	return ocmd.objnum
+}
+
+// OCGOrderEntry is an entry of the layer list in the viewer. It is either a
+// group or a label, both with optional nested entries shown below.
+type OCGOrderEntry struct {
+	Group *OptionalContentGroup
+	Label string
+	Kids  []*OCGOrderEntry
+}
+
+// NewOptionalContentGroup adds a layer with the name to the document. Use
+// it in the Properties of a page and mark the content stream with
+// /OC /<name> BDC ... EMC.
+func (pw *PDF) NewOptionalContentGroup(name string) *OptionalContentGroup {
+	ocg := &OptionalContentGroup{Name: name, Objnum: pw.NextObject()}
+	pw.ocgs = append(pw.ocgs, ocg)
+	return ocg
+}
+
+// NewOptionalContentMembership returns a membership dictionary for the
+// groups with the visibility policy AnyOn, AllOn, AnyOff or AllOff.
+func (pw *PDF) NewOptionalContentMembership(policy Name, groups ...*OptionalContentGroup) *OptionalContentMembership {
+	ocmd := &OptionalContentMembership{Groups: groups, Policy: policy, objnum: pw.NextObject()}
+	pw.ocmds = append(pw.ocmds, ocmd)
+	return ocmd
+}
+
+// groupRefs returns the PDF array of the groups.
+func groupRefs(groups []*OptionalContentGroup) string {
+	refs := make([]string, len(groups))
+	for i, g := range groups {
+		refs[i] = g.Objnum.Ref()
+	}
+	return "[" + strings.Join(refs, " ") + "]"
+}
+
+// orderItems returns the items of the /Order array for the entries and adds
+// the groups to seen.
+func orderItems(entries []*OCGOrderEntry, seen map[*OptionalContentGroup]bool) ([]string, error) {
+	var items []string
+	for _, e := range entries {
+		if (e.Group == nil) == (e.Label == "") {
+			return nil, fmt.Errorf("pdf: optional content order entry needs either a group or a label")
+		}
+		kids, err := orderItems(e.Kids, seen)
+		if err != nil {
+			return nil, err
+		}
+		if e.Group != nil {
+			seen[e.Group] = true
+			items = append(items, e.Group.Objnum.Ref())
+			if len(kids) > 0 {
+				items = append(items, "["+strings.Join(kids, " ")+"]")
+			}
+		} else {
+			items = append(items, "["+strings.Join(append([]string{stringToPDF(e.Label)}, kids...), " ")+"]")
+		}
+	}
+	return items, nil
+}
+
+// writeOptionalContent writes the groups and membership dictionaries and
+// returns the /OCProperties entry of the catalog, nil if the document has
+// no optional content.
+func (pw *PDF) writeOptionalContent() (Dict, error) {
+	if len(pw.ocgs) == 0 {
+		if len(pw.ocmds) > 0 || len(pw.OCGOrder) > 0 || len(pw.OCGRadioButtons) > 0 {
+			return nil, fmt.Errorf("pdf: optional content without groups")
+		}
+		return nil, nil
+	}
+	if pw.PDFA != nil && pw.PDFA.Level == PDFA1b {
+		pw.conformanceErrors = append(pw.conformanceErrors, fmt.Errorf("pdf: PDF/A-1 does not allow optional content"))
+	}
+	var off, locked []*OptionalContentGroup
+	usage := map[Name][]*OptionalContentGroup{}
+	for _, ocg := range pw.ocgs {
+		if ocg.Name == "" {
+			return nil, fmt.Errorf("pdf: optional content group without a name")
+		}
+		d := Dict{"Type": "/OCG", "Name": stringToPDF(ocg.Name)}
+		u := Dict{}
+		for _, s := range []struct {
+			event, key Name
+			state      Name
+		}{{"Print", "PrintState", ocg.PrintState}, {"View", "ViewState", ocg.ViewState}} {
+			switch s.state {
+			case "":
+				continue
+			case "ON", "OFF":
+			default:
+				return nil, fmt.Errorf("pdf: unknown %s %q of optional content group %q, want ON or OFF", s.key, s.state, ocg.Name)
+			}
+			u[s.event] = Dict{s.key: s.state.String()}
+			usage[s.event] = append(usage[s.event], ocg)
+		}
+		if len(u) > 0 {
+			d["Usage"] = u
+		}
+		if ocg.Off {
+			off = append(off, ocg)
+		}
+		if ocg.Locked {
+			locked = append(locked, ocg)
+		}
+		obj := pw.NewObjectWithNumber(ocg.Objnum)
+		obj.Dict(d)
+		if err := obj.Save(); err != nil {
+			return nil, err
+		}
+	}
+	for _, ocmd := range pw.ocmds {
+		d := Dict{"Type": "/OCMD", "OCGs": groupRefs(ocmd.Groups)}
+		switch ocmd.Policy {
+		case "", "AnyOn":
+		case "AllOn", "AnyOff", "AllOff":
+			d["P"] = ocmd.Policy.String()
+		default:
+			return nil, fmt.Errorf("pdf: unknown optional content policy %q", ocmd.Policy)
+		}
+		if len(ocmd.Groups) == 0 {
+			return nil, fmt.Errorf("pdf: optional content membership without groups")
+		}
+		obj := pw.NewObjectWithNumber(ocmd.objnum)
+		obj.Dict(d)
+		if err := obj.Save(); err != nil {
+			return nil, err
+		}
+	}
+
+	config := Dict{"Name": stringToPDF("Default")}
+	order := pw.OCGOrder
+	if order == nil {
+		for _, ocg := range pw.ocgs {
+			order = append(order, &OCGOrderEntry{Group: ocg})
+		}
+	}
+	seen := map[*OptionalContentGroup]bool{}
+	items, err := orderItems(order, seen)
+	if err != nil {
+		return nil, err
+	}
+	config["Order"] = "[" + strings.Join(items, " ") + "]"
+	if pw.PDFA != nil && len(seen) < len(pw.ocgs) {
+		pw.conformanceErrors = append(pw.conformanceErrors, fmt.Errorf("pdf: PDF/A requires all optional content groups in the order"))
+	}
+	if len(off) > 0 {
+		config["OFF"] = groupRefs(off)
+	}
+	if len(locked) > 0 {
+		config["Locked"] = groupRefs(locked)
+	}
+	if len(pw.OCGRadioButtons) > 0 {
+		rbgroups := make([]string, len(pw.OCGRadioButtons))
+		for i, rb := range pw.OCGRadioButtons {
+			visible := 0
+			for _, ocg := range rb {
+				if !ocg.Off {
+					visible++
+				}
+			}
+			if visible > 1 {
+				return nil, fmt.Errorf("pdf: more than one optional content group of a radio button group is on")
+			}
+			rbgroups[i] = groupRefs(rb)
+		}
+		config["RBGroups"] = "[" + strings.Join(rbgroups, " ") + "]"
+	}
+	// the usage application dictionaries make viewers apply the print and
+	// view states. PDF/A does not allow them.
+	if len(usage) > 0 && pw.PDFA == nil {
+		var as []string
+		for _, event := range []Name{"View", "Print"} {
+			if groups := usage[event]; len(groups) > 0 {
+				as = append(as, hashToString(Dict{"Event": event.String(), "Category": "[" + event.String() + "]", "OCGs": groupRefs(groups)}, 1))
+			}
+		}
+		config["AS"] = "[" + strings.Join(as, " ") + "]"
+	}
+	return Dict{"OCGs": groupRefs(pw.ocgs), "D": config}, nil
+}
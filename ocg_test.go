@@ -0,0 +1,97 @@
+package pdf
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestOptionalContent(t *testing.T) {
+	pw, buf := newTestPDF()
+	dims := pw.NewOptionalContentGroup("Dimensions")
+	notes := pw.NewOptionalContentGroup("Notes")
+	notes.Off = true
+	notes.Locked = true
+	notes.PrintState = "OFF"
+	metric := pw.NewOptionalContentGroup("Metric")
+	imperial := pw.NewOptionalContentGroup("Imperial")
+	imperial.Off = true
+	both := pw.NewOptionalContentMembership("AllOn", dims, metric)
+	pw.OCGOrder = []*OCGOrderEntry{
+		{Group: dims, Kids: []*OCGOrderEntry{{Group: notes}}},
+		{Label: "Units", Kids: []*OCGOrderEntry{{Group: metric}, {Group: imperial}}},
+	}
+	pw.OCGRadioButtons = [][]*OptionalContentGroup{{metric, imperial}}
+
+	content := pw.NewObject()
+	content.Data.WriteString("/OC /oc1 BDC 0 0 m 10 10 l S EMC")
+	page := pw.AddPage(content, 0)
+	page.Properties = map[Name]OptionalContent{"oc1": dims, "oc2": both}
+	annot := NewSquareAnnotation([4]float64{0, 0, 10, 10})
+	annot.OptionalContent = notes
+	page.Annotations = append(page.Annotations, annot)
+	if err := pw.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"/OCProperties <<",
+		fmt.Sprintf("/OCGs [%s %s %s %s]", dims.Objnum.Ref(), notes.Objnum.Ref(), metric.Objnum.Ref(), imperial.Objnum.Ref()),
+		"/Type /OCG",
+		"/Name (Dimensions)",
+		"/PrintState /OFF",
+		"/Type /OCMD",
+		"/P /AllOn",
+		fmt.Sprintf("/Order [%s [%s] [(Units) %s %s]]", dims.Objnum.Ref(), notes.Objnum.Ref(), metric.Objnum.Ref(), imperial.Objnum.Ref()),
+		fmt.Sprintf("/OFF [%s %s]", notes.Objnum.Ref(), imperial.Objnum.Ref()),
+		fmt.Sprintf("/Locked [%s]", notes.Objnum.Ref()),
+		fmt.Sprintf("/RBGroups [[%s %s]]", metric.Objnum.Ref(), imperial.Objnum.Ref()),
+		"/Event /Print",
+		fmt.Sprintf("/oc1 %s", dims.Objnum.Ref()),
+		fmt.Sprintf("/oc2 %s", both.objnum.Ref()),
+		fmt.Sprintf("/OC %s", notes.Objnum.Ref()),
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q", want)
+		}
+	}
+}
+
+func TestOptionalContentImage(t *testing.T) {
+	pw, _ := newTestPDF()
+	layer := pw.NewOptionalContentGroup("Photos")
+	img, err := pw.LoadImageFile(writeTempPNG(t, t.TempDir(), 4, 4, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	img.SetOptionalContent(layer)
+	if got := img.pendingDictEntries["OC"]; got != layer.Objnum.Ref() {
+		t.Errorf("image /OC = %q, want %q", got, layer.Objnum.Ref())
+	}
+}
+
+func TestOptionalContentErrors(t *testing.T) {
+	for _, setup := range []func(pw *PDF){
+		func(pw *PDF) { pw.NewOptionalContentGroup("") },
+		func(pw *PDF) { pw.NewOptionalContentGroup("a").ViewState = "On" },
+		func(pw *PDF) { pw.NewOptionalContentMembership("AnyOn") },
+		func(pw *PDF) { pw.NewOptionalContentMembership("Some", pw.NewOptionalContentGroup("a")) },
+		func(pw *PDF) { pw.OCGOrder = []*OCGOrderEntry{{}} },
+		func(pw *PDF) {
+			pw.OCGRadioButtons = [][]*OptionalContentGroup{{pw.NewOptionalContentGroup("a"), pw.NewOptionalContentGroup("b")}}
+		},
+	} {
+		pw, _ := newTestPDF()
+		setup(pw)
+		pw.AddPage(pw.NewObject(), 0)
+		if err := pw.Finish(); err == nil {
+			t.Error("expected an error")
+		}
+	}
+
+	if _, err := writePDFATestPDF(t, &PDFA{Level: PDFA1b, ICCProfile: testICCProfile("RGB ")}, func(pw *PDF) {
+		pw.NewOptionalContentGroup("a")
+	}); err == nil {
+		t.Error("expected an error for optional content in PDF/A-1")
+	}
+}
@@ -0,0 +1,147 @@
+package pdf
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// PageLabelStyle is the numbering style of a page label range.
+type PageLabelStyle int
+
+const (
+	// PageLabelNone shows only the prefix.
+	PageLabelNone PageLabelStyle = iota
+	// PageLabelDecimal numbers pages 1, 2, 3.
+	PageLabelDecimal
+	// PageLabelRomanLower numbers pages i, ii, iii.
+	PageLabelRomanLower
+	// PageLabelRomanUpper numbers pages I, II, III.
+	PageLabelRomanUpper
+	// PageLabelLettersLower numbers pages a to z, then aa to zz and so on.
+	PageLabelLettersLower
+	// PageLabelLettersUpper numbers pages A to Z, then AA to ZZ and so on.
+	PageLabelLettersUpper
+)
+
+var pageLabelStyleNames = map[PageLabelStyle]string{
+	PageLabelDecimal:      "/D",
+	PageLabelRomanLower:   "/r",
+	PageLabelRomanUpper:   "/R",
+	PageLabelLettersLower: "/a",
+	PageLabelLettersUpper: "/A",
+}
+
+// PageLabel starts a range of page labels. The range extends to the page
+// before the next range. Viewers show the labels instead of the page numbers,
+// for example "iv" for the front matter of a book.
+type PageLabel struct {
+	// PageIndex is the index of the first page of the range, starting at 0.
+	PageIndex int
+	Style     PageLabelStyle
+	Prefix    string
+	// Start is the number of the first page in the range, defaults to 1.
+	Start int
+}
+
+// label returns the label of the page n pages after the start of the range.
+func (pl PageLabel) label(n int) string {
+	num := max(pl.Start, 1) + n
+	var s string
+	switch pl.Style {
+	case PageLabelDecimal:
+		s = strconv.Itoa(num)
+	case PageLabelRomanLower:
+		s = strings.ToLower(romanNumeral(num))
+	case PageLabelRomanUpper:
+		s = romanNumeral(num)
+	case PageLabelLettersLower:
+		s = strings.ToLower(letterNumeral(num))
+	case PageLabelLettersUpper:
+		s = letterNumeral(num)
+	}
+	return pl.Prefix + s
+}
+
+// romanNumeral returns n in upper case roman numerals.
+func romanNumeral(n int) string {
+	values := []int{1000, 900, 500, 400, 100, 90, 50, 40, 10, 9, 5, 4, 1}
+	symbols := []string{"M", "CM", "D", "CD", "C", "XC", "L", "XL", "X", "IX", "V", "IV", "I"}
+	var b strings.Builder
+	for i, v := range values {
+		for n >= v {
+			b.WriteString(symbols[i])
+			n -= v
+		}
+	}
+	return b.String()
+}
+
+// letterNumeral returns A to Z for 1 to 26, AA to ZZ for 27 to 52 and so on.
+func letterNumeral(n int) string {
+	return strings.Repeat(string(rune('A'+(n-1)%26)), (n-1)/26+1)
+}
+
+// sortedPageLabels returns the page label ranges sorted by page index, with a
+// decimal range for the first page if none is given.
+func (pw *PDF) sortedPageLabels() ([]PageLabel, error) {
+	labels := slices.Clone(pw.PageLabels)
+	slices.SortStableFunc(labels, func(a, b PageLabel) int { return a.PageIndex - b.PageIndex })
+	for i, pl := range labels {
+		if pl.PageIndex < 0 || pl.PageIndex >= len(pw.pages.Pages) {
+			return nil, fmt.Errorf("pdf: page label for page index %d, but the document has %d pages", pl.PageIndex, len(pw.pages.Pages))
+		}
+		if i > 0 && labels[i-1].PageIndex == pl.PageIndex {
+			return nil, fmt.Errorf("pdf: more than one page label for page index %d", pl.PageIndex)
+		}
+		if pl.Start < 0 {
+			return nil, fmt.Errorf("pdf: page label start %d must not be negative", pl.Start)
+		}
+		if _, ok := pageLabelStyleNames[pl.Style]; !ok && pl.Style != PageLabelNone {
+			return nil, fmt.Errorf("pdf: unknown page label style %d", pl.Style)
+		}
+	}
+	if len(labels) > 0 && labels[0].PageIndex != 0 {
+		labels = slices.Insert(labels, 0, PageLabel{Style: PageLabelDecimal})
+	}
+	return labels, nil
+}
+
+// PageLabel returns the label of the page with the index (starting at 0) as
+// shown by viewers. Without page labels this is the page number.
+func (pw *PDF) PageLabel(index int) string {
+	labels, err := pw.sortedPageLabels()
+	if err != nil || len(labels) == 0 {
+		return strconv.Itoa(index + 1)
+	}
+	i, found := slices.BinarySearchFunc(labels, index, func(pl PageLabel, idx int) int { return pl.PageIndex - idx })
+	if !found {
+		i--
+	}
+	return labels[i].label(index - labels[i].PageIndex)
+}
+
+// writePageLabels writes the /PageLabels number tree and returns its root, 0
+// if the document has no page labels.
+func (pw *PDF) writePageLabels() (Objectnumber, error) {
+	labels, err := pw.sortedPageLabels()
+	if err != nil || len(labels) == 0 {
+		return 0, err
+	}
+	nums := make(map[int]any, len(labels))
+	for _, pl := range labels {
+		d := Dict{"Type": "/PageLabel"}
+		if pl.Style != PageLabelNone {
+			d["S"] = pageLabelStyleNames[pl.Style]
+		}
+		if pl.Prefix != "" {
+			d["P"] = stringToPDF(pl.Prefix)
+		}
+		if pl.Start > 1 {
+			d["St"] = strconv.Itoa(pl.Start)
+		}
+		nums[pl.PageIndex] = d
+	}
+	return pw.WriteNumberTree(nums)
+}
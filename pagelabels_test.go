@@ -0,0 +1,85 @@
+package pdf
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPageLabels(t *testing.T) {
+	pw, buf := newTestPDF()
+	for range 12 {
+		pw.AddPage(pw.NewObject(), 0)
+	}
+	pw.PageLabels = []PageLabel{
+		{PageIndex: 10, Style: PageLabelLettersUpper, Prefix: "A-"},
+		{PageIndex: 0, Style: PageLabelRomanLower},
+		{PageIndex: 4, Style: PageLabelDecimal},
+		{PageIndex: 8, Style: PageLabelDecimal, Start: 100},
+		{PageIndex: 9, Prefix: "Cover"},
+	}
+	want := []string{"i", "ii", "iii", "iv", "1", "2", "3", "4", "100", "Cover", "A-A", "A-B"}
+	for i, w := range want {
+		if got := pw.PageLabel(i); got != w {
+			t.Errorf("PageLabel(%d) = %q, want %q", i, got, w)
+		}
+	}
+	if err := pw.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, w := range []string{
+		"/PageLabels ",
+		"/Nums [0 <<",
+		"/S /r",
+		"/St 100",
+		"/P (Cover)",
+		"/P (A-)",
+		"/S /A",
+	} {
+		if !strings.Contains(out, w) {
+			t.Errorf("output does not contain %q", w)
+		}
+	}
+}
+
+func TestPageLabelsDefaultFirstRange(t *testing.T) {
+	pw, _ := newTestPDF()
+	for range 3 {
+		pw.AddPage(pw.NewObject(), 0)
+	}
+	pw.PageLabels = []PageLabel{{PageIndex: 2, Style: PageLabelRomanUpper, Start: 4}}
+	for i, w := range []string{"1", "2", "IV"} {
+		if got := pw.PageLabel(i); got != w {
+			t.Errorf("PageLabel(%d) = %q, want %q", i, got, w)
+		}
+	}
+}
+
+func TestPageLabelsErrors(t *testing.T) {
+	for _, labels := range [][]PageLabel{
+		{{PageIndex: 5}},
+		{{PageIndex: 0}, {PageIndex: 0, Style: PageLabelDecimal}},
+		{{PageIndex: 0, Start: -1}},
+		{{PageIndex: 0, Style: PageLabelStyle(42)}},
+	} {
+		pw, _ := newTestPDF()
+		pw.AddPage(pw.NewObject(), 0)
+		pw.PageLabels = labels
+		if err := pw.Finish(); err == nil {
+			t.Errorf("expected an error for %v", labels)
+		}
+	}
+}
+
+func TestRomanAndLetterNumerals(t *testing.T) {
+	for n, want := range map[int]string{1: "I", 4: "IV", 9: "IX", 14: "XIV", 40: "XL", 1994: "MCMXCIV"} {
+		if got := romanNumeral(n); got != want {
+			t.Errorf("romanNumeral(%d) = %q, want %q", n, got, want)
+		}
+	}
+	for n, want := range map[int]string{1: "A", 26: "Z", 27: "AA", 53: "AAA"} {
+		if got := letterNumeral(n); got != want {
+			t.Errorf("letterNumeral(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package pdf
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// defaultPageTreeFanout is the maximum number of kids of a /Pages node if
+// PDF.PageTreeFanout is not set.
+const defaultPageTreeFanout = 32
+
+// inheritablePageKeys are the page attributes that can be placed on a /Pages
+// node and are inherited by all pages below it.
+var inheritablePageKeys = []Name{"Resources", "MediaBox", "CropBox", "Rotate"}
+
+// pageTreeNode is a page or an intermediate /Pages node.
+type pageTreeNode struct {
+	obj   *Object
+	dict  Dict
+	kids  []*pageTreeNode
+	count int
+}
+
+// hoist moves the inheritable attributes that all kids share to the node.
+// It works bottom up, so that an attribute can travel up to the root.
+func (n *pageTreeNode) hoist() {
+	for _, kid := range n.kids {
+		kid.hoist()
+	}
+	if len(n.kids) == 0 {
+		return
+	}
+	for _, key := range inheritablePageKeys {
+		v, ok := n.kids[0].dict[key]
+		if !ok {
+			continue
+		}
+		value := Serialize(v)
+		shared := true
+		for _, kid := range n.kids[1:] {
+			if kv, ok := kid.dict[key]; !ok || Serialize(kv) != value {
+				shared = false
+				break
+			}
+		}
+		if !shared {
+			continue
+		}
+		n.dict[key] = v
+		for _, kid := range n.kids {
+			delete(kid.dict, key)
+		}
+	}
+}
+
+// save writes the node and all nodes below it. Pages are leaves.
+func (n *pageTreeNode) save(parent Objectnumber) error {
+	if parent != 0 {
+		n.dict["Parent"] = parent.Ref()
+	}
+	if n.kids != nil {
+		kids := make([]string, len(n.kids))
+		for i, kid := range n.kids {
+			kids[i] = kid.obj.ObjectNumber.Ref()
+		}
+		n.dict["Type"] = "/Pages"
+		n.dict["Kids"] = "[ " + strings.Join(kids, " ") + " ]"
+		n.dict["Count"] = strconv.Itoa(n.count)
+	}
+	n.obj.Dict(n.dict)
+	if err := n.obj.Save(); err != nil {
+		return err
+	}
+	for _, kid := range n.kids {
+		if err := kid.save(n.obj.ObjectNumber); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writePageTree writes the page dictionaries below a balanced tree of /Pages
+// nodes with at most PageTreeFanout kids each. Inheritable attributes shared
+// by all pages below a node are moved to that node.
+func (pw *PDF) writePageTree(root *Object, pages []*Object) error {
+	fanout := pw.PageTreeFanout
+	if fanout < 2 {
+		fanout = defaultPageTreeFanout
+	}
+	level := make([]*pageTreeNode, len(pages))
+	for i, p := range pages {
+		d := p.Dictionary
+		// allow the inheritable keys in Page.Dict to be given with a slash
+		for _, key := range inheritablePageKeys {
+			if v, ok := d["/"+key]; ok {
+				d[key] = v
+				delete(d, "/"+key)
+			}
+		}
+		level[i] = &pageTreeNode{obj: p, dict: d, count: 1}
+	}
+	for len(level) > fanout {
+		var next []*pageTreeNode
+		for chunk := range slices.Chunk(level, fanout) {
+			node := &pageTreeNode{obj: pw.NewObject(), dict: Dict{}, kids: chunk}
+			for _, kid := range chunk {
+				node.count += kid.count
+			}
+			next = append(next, node)
+		}
+		level = next
+	}
+	top := &pageTreeNode{obj: root, dict: Dict{}, kids: level}
+	for _, kid := range level {
+		top.count += kid.count
+	}
+	top.hoist()
+	return top.save(0)
+}
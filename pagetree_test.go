@@ -0,0 +1,68 @@
+package pdf
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestPageTreeFlat(t *testing.T) {
+	pw, buf := newTestPDF()
+	pw.DefaultPageWidth, pw.DefaultPageHeight = 595, 842
+	for range 3 {
+		pw.AddPage(pw.NewObject(), 0)
+	}
+	if err := pw.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if got := strings.Count(out, "/Type /Pages"); got != 1 {
+		t.Errorf("got %d /Pages nodes, want 1", got)
+	}
+	if got := strings.Count(out, "/MediaBox [0 0 595 842]"); got != 1 {
+		t.Errorf("MediaBox written %d times, want once on the root", got)
+	}
+}
+
+func TestPageTreeBalanced(t *testing.T) {
+	pw, buf := newTestPDF()
+	pw.PageTreeFanout = 4
+	pw.DefaultPageWidth, pw.DefaultPageHeight = 595, 842
+	var pages []*Page
+	for range 20 {
+		pages = append(pages, pw.AddPage(pw.NewObject(), 0))
+	}
+	// the last four pages are landscape and rotated
+	for _, p := range pages[16:] {
+		p.Width, p.Height = 842, 595
+		p.Dict = Dict{"/Rotate": "90"}
+	}
+	if err := pw.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	// 20 pages / 4 = 5 nodes, 5 / 4 = 2 nodes, plus the root
+	if got := strings.Count(out, "/Type /Pages"); got != 8 {
+		t.Errorf("got %d /Pages nodes, want 8", got)
+	}
+	if got := len(regexp.MustCompile(`/Kids \[ (\d+ 0 R ?){1,4}\]`).FindAllString(out, -1)); got != 8 {
+		t.Errorf("got %d nodes with at most 4 kids, want 8", got)
+	}
+	if !strings.Contains(out, "/Count 20") || !strings.Contains(out, "/Count 16") {
+		t.Error("wrong page counts")
+	}
+	// portrait on the node for the first 16 pages, landscape on the node
+	// for the last four
+	if got := strings.Count(out, "/MediaBox [0 0 595 842]"); got != 1 {
+		t.Errorf("portrait MediaBox written %d times, want 1", got)
+	}
+	if got := strings.Count(out, "/MediaBox [0 0 842 595]"); got != 1 {
+		t.Errorf("landscape MediaBox written %d times, want 1", got)
+	}
+	if got := strings.Count(out, "/Rotate 90"); got != 1 {
+		t.Errorf("/Rotate written %d times, want 1", got)
+	}
+	if strings.Contains(out, "/Rotate 90\n /Type /Page\n") {
+		t.Error("/Rotate should be inherited")
+	}
+}
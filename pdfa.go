@@ -0,0 +1,183 @@
+package pdf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// PDFALevel is a PDF/A conformance level. Only level B (visual appearance)
+// is supported. The zero value is no level, checkPDFASettings rejects it.
+type PDFALevel int
+
+const (
+	// PDFA1b is ISO 19005-1, level B. It does not allow transparency or
+	// object streams.
+	PDFA1b PDFALevel = iota + 1
+	// PDFA2b is ISO 19005-2, level B.
+	PDFA2b
+	// PDFA3b is ISO 19005-3, level B. It allows arbitrary embedded files.
+	PDFA3b
+)
+
+// part returns the value of the pdfaid:part XMP property.
+func (l PDFALevel) part() string {
+	return strconv.Itoa(int(l))
+}
+
+// PDFA holds the settings for writing a PDF/A file. Set PDF.PDFA before
+// calling Finish. The writer then adds the XMP metadata and the output
+// intent, and Finish returns an error for anything PDF/A does not allow
+// instead of producing a non-conforming file.
+type PDFA struct {
+	Level PDFALevel
+	// ICCProfile is the ICC profile of the output intent, for example sRGB
+	// for screen documents or a CMYK profile for print.
+	ICCProfile []byte
+	// OutputConditionIdentifier names the output condition, for example
+	// "sRGB IEC61966-2.1" or "FOGRA39". Defaults to "Custom".
+	OutputConditionIdentifier string
+	// OutputCondition is an optional human-readable description.
+	OutputCondition string
+	// RegistryName is the registry of the output condition identifier,
+	// such as "http://www.color.org".
+	RegistryName string
+}
+
+// iccComponents returns the number of colour components of an ICC profile
+// as read from its header.
+func iccComponents(profile []byte) (int, error) {
+	if len(profile) < 128 || string(profile[36:40]) != "acsp" {
+		return 0, errors.New("pdf: not an ICC profile")
+	}
+	switch string(profile[16:20]) {
+	case "GRAY":
+		return 1, nil
+	case "RGB ", "Lab ", "XYZ ":
+		return 3, nil
+	case "CMYK":
+		return 4, nil
+	}
+	return 0, fmt.Errorf("pdf: unsupported ICC profile colour space %q", profile[16:20])
+}
+
+// checkPDFASettings reports the document level settings that conflict with
+// the requested PDF/A conformance.
+func (pw *PDF) checkPDFASettings() error {
+	var errs []error
+	switch pw.PDFA.Level {
+	case PDFA1b, PDFA2b, PDFA3b:
+	default:
+		errs = append(errs, fmt.Errorf("pdf: unknown PDF/A level %d", pw.PDFA.Level))
+	}
+	if pw.version != Version17 {
+		errs = append(errs, fmt.Errorf("pdf: PDF/A-%s requires PDF 1.7 or earlier, not %s", pw.PDFA.Level.part(), pw.version))
+	}
+	if pw.security != nil {
+		errs = append(errs, errors.New("pdf: PDF/A does not allow encryption"))
+	}
+	if pw.PDFA.Level == PDFA1b && pw.ObjectStreams {
+		errs = append(errs, errors.New("pdf: PDF/A-1 does not allow object streams"))
+	}
+	if len(pw.PDFA.ICCProfile) == 0 {
+		errs = append(errs, errors.New("pdf: PDF/A requires an ICC profile for the output intent"))
+	} else if _, err := iccComponents(pw.PDFA.ICCProfile); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+var (
+	rawFontDescriptor = regexp.MustCompile(`/Type\s*/FontDescriptor\b`)
+	rawFontFile       = regexp.MustCompile(`/FontFile[23]?\b`)
+	// rawSMask captures the value of an /SMask entry: a name or the first
+	// character of a reference or dictionary.
+	rawSMask = regexp.MustCompile(`/SMask\b\s*(/[^\s/<>\[\]()%]*|\S)`)
+)
+
+// checkConformance records the PDF/A violations of an object that is about to
+// be written. They are reported by Finish.
+func (pw *PDF) checkConformance(obj *Object) {
+	fail := func(format string, a ...any) {
+		pw.conformanceErrors = append(pw.conformanceErrors,
+			fmt.Errorf("pdf: object %d: "+format, append([]any{obj.ObjectNumber}, a...)...))
+	}
+	if obj.Raw {
+		data := obj.Data.Bytes()
+		if bytes.Contains(data, []byte("/LZWDecode")) {
+			fail("PDF/A does not allow LZW compression")
+		}
+		if rawFontDescriptor.Match(data) && !rawFontFile.Match(data) {
+			fail("font is not embedded")
+		}
+		if pw.PDFA.Level == PDFA1b {
+			for _, m := range rawSMask.FindAllSubmatch(data, -1) {
+				if string(m[1]) != "/None" {
+					fail("PDF/A-1 does not allow transparency")
+					break
+				}
+			}
+		}
+		return
+	}
+	d := obj.Dictionary
+	if strings.Contains(fmt.Sprint(d["Filter"]), "LZWDecode") {
+		fail("PDF/A does not allow LZW compression")
+	}
+	typ := fmt.Sprint(d["Type"])
+	if typ == "/FontDescriptor" && d["FontFile"] == nil && d["FontFile2"] == nil && d["FontFile3"] == nil {
+		fail("font %s is not embedded", d["FontName"])
+	}
+	if typ == "/Font" && d["FontDescriptor"] == nil {
+		switch fmt.Sprint(d["Subtype"]) {
+		case "/Type1", "/MMType1", "/TrueType":
+			fail("font %s is not embedded", d["BaseFont"])
+		}
+	}
+	if pw.PDFA.Level == PDFA1b {
+		if sm, ok := d["SMask"]; ok && fmt.Sprint(sm) != "/None" {
+			fail("PDF/A-1 does not allow transparency")
+		}
+		if strings.Contains(fmt.Sprint(d["Group"]), "/Transparency") {
+			fail("PDF/A-1 does not allow transparency groups")
+		}
+	}
+	if g := fmt.Sprint(d["Group"]); strings.Contains(g, "/Transparency") && !strings.Contains(g, "/CS") && typ == "/Page" && pw.PDFA.Level != PDFA1b {
+		// The output intent supplies the blending colour space, but only
+		// if it matches the colour space of the group. Be explicit.
+		fail("transparency group on page without a group colour space")
+	}
+}
+
+// writeOutputIntent writes the ICC profile and the PDF/A output intent and
+// returns the value for the /OutputIntents entry of the catalog.
+func (pw *PDF) writeOutputIntent() (string, error) {
+	profile, _, err := pw.iccProfileObject(pw.PDFA.ICCProfile, "")
+	if err != nil {
+		return "", err
+	}
+	id := pw.PDFA.OutputConditionIdentifier
+	if id == "" {
+		id = "Custom"
+	}
+	intent := pw.NewObject()
+	intent.Dictionary = Dict{
+		"Type":                      "/OutputIntent",
+		"S":                         "/GTS_PDFA1",
+		"OutputConditionIdentifier": stringToPDF(id),
+		"DestOutputProfile":         profile.Ref(),
+	}
+	if pw.PDFA.OutputCondition != "" {
+		intent.Dictionary["OutputCondition"] = stringToPDF(pw.PDFA.OutputCondition)
+	}
+	if pw.PDFA.RegistryName != "" {
+		intent.Dictionary["RegistryName"] = stringToPDF(pw.PDFA.RegistryName)
+	}
+	if err := intent.Save(); err != nil {
+		return "", err
+	}
+	return "[" + intent.ObjectNumber.Ref() + "]", nil
+}
@@ -0,0 +1,163 @@
+package pdf
+
+import (
+	"strings"
+	"testing"
+)
+
+// testICCProfile returns an ICC profile header that is good enough for the
+// writer, which only looks at the colour space.
+func testICCProfile(colorspace string) []byte {
+	p := make([]byte, 132)
+	copy(p[12:], "mntr")
+	copy(p[16:], colorspace)
+	copy(p[20:], "XYZ ")
+	copy(p[36:], "acsp")
+	p[3] = byte(len(p))
+	return p
+}
+
+func writePDFATestPDF(t *testing.T, pdfa *PDFA, setup func(pw *PDF)) (string, error) {
+	t.Helper()
+	pw, buf := newTestPDF()
+	pw.PDFA = pdfa
+	pw.InfoDict["Title"] = stringToPDF("Grüße & <Tests>")
+	pw.InfoDict["Author"] = stringToPDF("Jane Doe")
+	if setup != nil {
+		setup(pw)
+	}
+	content := pw.NewObject()
+	content.Data.WriteString("0 0 m 10 10 l S")
+	pw.AddPage(content, 0)
+	err := pw.Finish()
+	return buf.String(), err
+}
+
+func TestPDFA2b(t *testing.T) {
+	out, err := writePDFATestPDF(t, &PDFA{
+		Level:                     PDFA2b,
+		ICCProfile:                testICCProfile("RGB "),
+		OutputConditionIdentifier: "sRGB IEC61966-2.1",
+		RegistryName:              "http://www.color.org",
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"/Metadata ",
+		"/OutputIntents [",
+		"/S /GTS_PDFA1",
+		"/OutputConditionIdentifier (sRGB IEC61966-2.1)",
+		"/N 3",
+		"<pdfaid:part>2</pdfaid:part>",
+		"<pdfaid:conformance>B</pdfaid:conformance>",
+		`<rdf:li xml:lang="x-default">Grüße &amp; &lt;Tests&gt;</rdf:li>`,
+		"<rdf:li>Jane Doe</rdf:li>",
+		"<pdf:Producer>baseline-pdf/boxes and glue</pdf:Producer>",
+		"<xmp:CreatorTool>baseline-pdf</xmp:CreatorTool>",
+		"<xmp:CreateDate>",
+		`<?xpacket end="w"?>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q", want)
+		}
+	}
+}
+
+func TestPDFAAnnotationPrintFlag(t *testing.T) {
+	out, err := writePDFATestPDF(t, &PDFA{Level: PDFA3b, ICCProfile: testICCProfile("CMYK")}, func(pw *PDF) {
+		content := pw.NewObject()
+		page := pw.AddPage(content, 0)
+		page.Annotations = append(page.Annotations, Annotation{Subtype: "Link", Rect: [4]float64{0, 0, 10, 10}})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "/F 4") {
+		t.Error("annotation has no print flag")
+	}
+	if !strings.Contains(out, "/N 4") || !strings.Contains(out, "<pdfaid:part>3</pdfaid:part>") {
+		t.Error("expected CMYK output intent and part 3")
+	}
+}
+
+func TestPDFAViolations(t *testing.T) {
+	profile := testICCProfile("RGB ")
+	tests := []struct {
+		name  string
+		pdfa  *PDFA
+		setup func(pw *PDF)
+		want  string
+	}{
+		{"no level", &PDFA{ICCProfile: profile}, nil, "unknown PDF/A level 0"},
+		{"no profile", &PDFA{Level: PDFA2b}, nil, "requires an ICC profile"},
+		{"bad profile", &PDFA{Level: PDFA2b, ICCProfile: []byte("no profile")}, nil, "not an ICC profile"},
+		{"encryption", &PDFA{Level: PDFA2b, ICCProfile: profile}, func(pw *PDF) {
+			if err := pw.SetEncryption(&Encryption{}); err != nil {
+				t.Fatal(err)
+			}
+		}, "does not allow encryption"},
+		{"version 2.0", &PDFA{Level: PDFA3b, ICCProfile: profile}, func(pw *PDF) {
+			pw.SetVersion(Version20)
+		}, "requires PDF 1.7"},
+		{"object streams", &PDFA{Level: PDFA1b, ICCProfile: profile}, func(pw *PDF) {
+			pw.ObjectStreams = true
+		}, "does not allow object streams"},
+		{"lzw", &PDFA{Level: PDFA2b, ICCProfile: profile}, func(pw *PDF) {
+			obj := pw.NewObject()
+			obj.Dictionary = Dict{"Filter": "/LZWDecode"}
+			obj.Data.WriteString("x")
+			obj.Save()
+		}, "LZW"},
+		{"font not embedded", &PDFA{Level: PDFA2b, ICCProfile: profile}, func(pw *PDF) {
+			obj := pw.NewObject()
+			obj.Dictionary = Dict{"Type": "/Font", "Subtype": "/Type1", "BaseFont": "/Helvetica"}
+			obj.Save()
+		}, "font /Helvetica is not embedded"},
+		{"descriptor without file", &PDFA{Level: PDFA2b, ICCProfile: profile}, func(pw *PDF) {
+			obj := pw.NewObject()
+			obj.Raw = true
+			obj.Data.WriteString("<< /Type /FontDescriptor /FontName /Foo >>")
+			obj.Save()
+		}, "font is not embedded"},
+		{"transparency on PDF/A-1", &PDFA{Level: PDFA1b, ICCProfile: profile}, func(pw *PDF) {
+			obj := pw.NewObject()
+			obj.Dictionary = Dict{"Type": "/XObject", "Subtype": "/Image", "SMask": "5 0 R"}
+			obj.Save()
+		}, "PDF/A-1 does not allow transparency"},
+		{"raw transparency on PDF/A-1", &PDFA{Level: PDFA1b, ICCProfile: profile}, func(pw *PDF) {
+			obj := pw.NewObject()
+			obj.Raw = true
+			obj.Data.WriteString("<< /Type /ExtGState /SMask /None >>\n<< /Type /ExtGState /SMask 5 0 R >>")
+			obj.Save()
+		}, "PDF/A-1 does not allow transparency"},
+		{"page group without colour space", &PDFA{Level: PDFA2b, ICCProfile: profile}, func(pw *PDF) {
+			obj := pw.NewObject()
+			obj.Dictionary = Dict{"Type": "/Page", "Group": "<< /S /Transparency >>"}
+			obj.Save()
+		}, "without a group colour space"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := writePDFATestPDF(t, tt.pdfa, tt.setup)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error %q does not contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestPDFA1RawSMaskNone(t *testing.T) {
+	_, err := writePDFATestPDF(t, &PDFA{Level: PDFA1b, ICCProfile: testICCProfile("RGB ")}, func(pw *PDF) {
+		obj := pw.NewObject()
+		obj.Raw = true
+		obj.Data.WriteString("<< /Type /ExtGState /SMask/None /CA 1 >>")
+		obj.Save()
+	})
+	if err != nil {
+		t.Error(err)
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"fmt"
 	"math"
-	"sort"
 	"strconv"
 	"strings"
 	"unicode/utf16"
@@ -56,7 +55,10 @@ type NameDest struct {
 	PageObjectnumber Objectnumber
 	X                float64
 	Y                float64
-	objectnumber     Objectnumber
+	// Destination replaces PageObjectnumber, X and Y if set. It must be a
+	// page in this document.
+	Destination  *Destination
+	objectnumber Objectnumber
 }
 
 // NameTreeData is a map of strings to object numbers which is sorted by key and
@@ -68,12 +70,9 @@ type NameTreeData map[String]Objectnumber
 // hexadecimal form when placed in the PDF.
 type String string
 
-// stringToPDF returns an escaped string suitable to be used as a PDF object.
-// It uses PDFDocEncoding (parenthesized literal) when all characters are
-// representable, and falls back to UTF-16BE hex encoding otherwise.
-func stringToPDF(str string) string {
-	// Check if the string can be encoded in PDFDocEncoding.
-	canEncode := true
+// pdfDocEncodable reports whether all characters of str can be represented in
+// PDFDocEncoding.
+func pdfDocEncodable(str string) bool {
 	for _, r := range str {
 		if r <= 0x7F {
 			continue
@@ -85,12 +84,40 @@ func stringToPDF(str string) string {
 		if _, ok := unicodeToPDFDocEncoding[r]; ok {
 			continue
 		}
-		canEncode = false
-		break
+		return false
+	}
+	return true
+}
+
+// pdfStringBytes returns the bytes of str as encoded by stringToPDF, without
+// escapes: PDFDocEncoding or UTF-16BE with byte order mark. Name tree keys
+// are sorted by these bytes.
+func pdfStringBytes(str string) []byte {
+	if !pdfDocEncodable(str) {
+		out := []byte{0xfe, 0xff}
+		for _, i := range utf16.Encode([]rune(str)) {
+			out = append(out, byte(i>>8), byte(i))
+		}
+		return out
+	}
+	out := make([]byte, 0, len(str))
+	for _, r := range str {
+		switch {
+		case r <= 0x7F, r >= 0x00A1 && r <= 0x00FF:
+			out = append(out, byte(r))
+		default:
+			out = append(out, unicodeToPDFDocEncoding[r])
+		}
 	}
+	return out
+}
 
+// stringToPDF returns an escaped string suitable to be used as a PDF object.
+// It uses PDFDocEncoding (parenthesized literal) when all characters are
+// representable, and falls back to UTF-16BE hex encoding otherwise.
+func stringToPDF(str string) string {
 	var out strings.Builder
-	if canEncode {
+	if pdfDocEncodable(str) {
 		out.WriteRune('(')
 		for _, r := range str {
 			switch {
@@ -154,23 +181,27 @@ func serializeLevel(item any, level int) string {
 		return stringToPDF(string(t))
 	case NameTreeData:
 		// sort by key
-		var keys []string
-		for k := range t {
-			keys = append(keys, string(k))
-		}
-		sort.Strings(keys)
+		keys := t.sortedKeys()
 		var out strings.Builder
 		out.WriteString("[ ")
 		for _, k := range keys {
-			out.WriteString(stringToPDF(k))
+			out.WriteString(stringToPDF(string(k)))
 			out.WriteByte(' ')
-			out.WriteString(t[String(k)].Ref())
+			out.WriteString(t[k].Ref())
 			out.WriteByte(' ')
 		}
 		out.WriteString("]")
 		return out.String()
 	case Objectnumber:
 		return t.Ref()
+	case *Action:
+		return hashToString(t.dict(), level+1)
+	case AdditionalActions:
+		d := make(Dict, len(t))
+		for trigger, a := range t {
+			d[trigger] = a
+		}
+		return hashToString(d, level+1)
 	default:
 		return fmt.Sprintf("%v", t)
 	}
@@ -206,6 +237,9 @@ type Object struct {
 	ForceStream  bool // Write stream even if Data is empty
 	compress     bool // for streams
 	saved        bool // set to true when object is written to the PDF file
+	// unencrypted objects (the /Encrypt dictionary and the cross-reference
+	// stream) are written as they are and never put in an object stream.
+	unencrypted bool
 }
 
 // NewObjectWithNumber create a new PDF object and reserves an object
@@ -244,13 +278,40 @@ func (obj *Object) Save() error {
 		return nil
 	}
 	obj.saved = true
+	if obj.pdfwriter.PDFA != nil {
+		obj.pdfwriter.checkConformance(obj)
+	}
+	if obj.pdfwriter.ObjectStreams && obj.compressible() {
+		if obj.Raw {
+			return obj.pdfwriter.addToObjectStream(obj.ObjectNumber, obj.Data.Bytes())
+		}
+		var body string
+		if len(obj.Dictionary) > 0 {
+			body = hashToString(obj.Dictionary, 0)
+		} else if len(obj.Array) > 0 {
+			body = arrayToString(obj.Array)
+		}
+		return obj.pdfwriter.addToObjectStream(obj.ObjectNumber, []byte(body))
+	}
 	if obj.comment != "" {
 		if err := obj.pdfwriter.Print("\n% " + obj.comment); err != nil {
 			return err
 		}
 	}
 
+	sh := obj.pdfwriter.security
+	if obj.unencrypted {
+		sh = nil
+	}
+
 	if obj.Raw {
+		if sh != nil {
+			enc, err := sh.encryptRaw(obj.ObjectNumber, obj.Data.Bytes())
+			if err != nil {
+				return err
+			}
+			obj.Data = bytes.NewBuffer(enc)
+		}
 		err := obj.pdfwriter.startObject(obj.ObjectNumber)
 		if err != nil {
 			return err
@@ -283,17 +344,32 @@ func (obj *Object) Save() error {
 		} else {
 			obj.Dictionary["Length"] = strconv.Itoa(obj.Data.Len())
 		}
+		if sh != nil {
+			enc, err := sh.encrypt(obj.ObjectNumber, obj.Data.Bytes())
+			if err != nil {
+				return err
+			}
+			obj.Data = bytes.NewBuffer(enc)
+			obj.Dictionary["Length"] = strconv.Itoa(obj.Data.Len())
+		}
 	}
 
 	obj.pdfwriter.startObject(obj.ObjectNumber)
+	var body string
 	if len(obj.Dictionary) > 0 {
-		n, err := fmt.Fprint(obj.pdfwriter.outfile, hashToString(obj.Dictionary, 0))
+		body = hashToString(obj.Dictionary, 0)
+	} else if len(obj.Array) > 0 {
+		body = arrayToString(obj.Array)
+	}
+	if sh != nil && body != "" {
+		enc, err := sh.encryptStrings(obj.ObjectNumber, []byte(body))
 		if err != nil {
 			return err
 		}
-		obj.pdfwriter.pos += int64(n)
-	} else if len(obj.Array) > 0 {
-		n, err := fmt.Fprint(obj.pdfwriter.outfile, arrayToString(obj.Array))
+		body = string(enc)
+	}
+	if body != "" {
+		n, err := fmt.Fprint(obj.pdfwriter.outfile, body)
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,101 @@
+package pdf
+
+// Resources are the named resources of a content stream which is not a page,
+// such as a tiling pattern or a Form XObject. The names (without the leading
+// slash) are the ones used in the content stream.
+type Resources struct {
+	Faces  []*Face
+	Images []*Imagefile
+	// Forms are painted with <InternalName> Do, like images.
+	Forms []*FormXObject
+	// Patterns are shading or tiling patterns, used with /<name> scn.
+	Patterns map[Name]*Object
+	// Shadings are painted with /<name> sh.
+	Shadings   map[Name]*Object
+	ExtGStates map[Name]*ExtGState
+	Properties map[Name]OptionalContent
+	// ColorSpaces are colour space arrays such as [/Pattern /DeviceRGB] or
+	// references to them, set with /<name> cs. The document's Colorspaces
+	// are always included.
+	ColorSpaces map[Name]Name
+}
+
+// useResources makes sure that the faces and images of resources outside
+// of pages are written when the document is finished.
+func (pw *PDF) useResources(r Resources) {
+	pw.resourceFaces = append(pw.resourceFaces, r.Faces...)
+	for _, img := range r.Images {
+		img.ImageObject()
+		pw.resourceImages = append(pw.resourceImages, img)
+	}
+}
+
+// resourceDict returns the /Resources dictionary, writing the graphics
+// states if necessary.
+func (pw *PDF) resourceDict(r Resources) (Dict, error) {
+	resHash := Dict{}
+	if len(r.Faces) > 0 {
+		fnts := Dict{}
+		for _, face := range r.Faces {
+			fnts[Name(face.InternalName())] = face.fontobject.ObjectNumber.Ref()
+		}
+		resHash["Font"] = fnts
+	}
+	if len(pw.Colorspaces) > 0 || len(r.ColorSpaces) > 0 {
+		colorspace := Dict{}
+
+		for _, cs := range pw.Colorspaces {
+			colorspace[Name(cs.ID)] = cs.Obj.String()
+		}
+		for name, cs := range r.ColorSpaces {
+			colorspace[name] = string(cs)
+		}
+		resHash["ColorSpace"] = colorspace
+	}
+	if len(r.Images) > 0 || len(r.Forms) > 0 {
+		xo := Dict{}
+		for _, img := range r.Images {
+			xo[Name(img.InternalName())] = img.ImageObject().ObjectNumber.Ref()
+		}
+		for _, fx := range r.Forms {
+			xo[Name(fx.InternalName())] = fx.Objnum.Ref()
+		}
+		resHash["XObject"] = xo
+	}
+	// Shading and tiling patterns. Pattern names are passed through
+	// verbatim; the SVG renderer reuses the same names in the page content
+	// stream.
+	if len(r.Patterns) > 0 {
+		pat := Dict{}
+		for name, obj := range r.Patterns {
+			pat[name] = obj.ObjectNumber.Ref()
+		}
+		resHash["Pattern"] = pat
+	}
+	if len(r.Shadings) > 0 {
+		shadings := Dict{}
+		for name, obj := range r.Shadings {
+			shadings[name] = obj.ObjectNumber.Ref()
+		}
+		resHash["Shading"] = shadings
+	}
+	if len(r.ExtGStates) > 0 {
+		gstates := Dict{}
+		for name, gs := range r.ExtGStates {
+			num, err := pw.extGStateObject(gs)
+			if err != nil {
+				return nil, err
+			}
+			gstates[name] = num.Ref()
+		}
+		resHash["ExtGState"] = gstates
+	}
+	if len(r.Properties) > 0 {
+		props := Dict{}
+		for name, oc := range r.Properties {
+			props[name] = oc.ocObjectnumber().Ref()
+		}
+		resHash["Properties"] = props
+	}
+	return resHash, nil
+}
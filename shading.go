@@ -7,37 +7,92 @@ import (
 
 // Function describes a PDF Function (Section 7.10 of ISO 32000-1).
 //
-// Two FunctionTypes are supported here:
+// Four FunctionTypes are supported here:
+//
+//   - FunctionType 0 (sampled) — a table of Samples on a grid of Size
+//     points per input, for non-linear colour ramps and tint transforms.
+//     The samples are written as a compressed stream.
 //
 //   - FunctionType 2 (exponential interpolation) — used for a two-stop linear
-//     gradient. C0/C1 are the RGB endpoints in 0..1 space; N=1 yields linear
-//     interpolation, the only mode used for SVG.
+//     gradient. C0/C1 are the endpoint colours in 0..1 space with one value
+//     per colour component (1 for gray and Separation, 3 for RGB, 4 for
+//     CMYK, n for DeviceN). Nil C0 and C1 default to [0] and [1]; in a
+//     shading they are zeros and ones for each component of its colour
+//     space, so a black to white RGB gradient needs neither. N=1 yields
+//     linear interpolation, the only mode used for SVG.
+//
+//   - FunctionType 3 (stitching) — chains multiple one-input sub-functions
+//     for gradients with three or more stops. Bounds partition the [0,1]
+//     domain and Encode remaps each sub-domain onto its sub-function's [0,1].
 //
-//   - FunctionType 3 (stitching) — chains multiple Type 2 sub-functions for
-//     gradients with three or more stops. Bounds partition the [0,1] domain
-//     and Encode remaps each sub-domain onto its sub-function's [0,1].
+//   - FunctionType 4 (PostScript calculator) — a small PostScript program in
+//     Code such as "{ 1 exch sub }", for compact tint transforms.
 type Function struct {
-	FunctionType int        // 2 or 3
-	Domain       [2]float64 // typically [0 1]
+	FunctionType int // 0, 2, 3 or 4
+	// Domain has a min/max pair per input; nil means [0 1]. Types 2 and 3
+	// have exactly one input.
+	Domain []float64
+	// Range has a min/max pair per output. Types 0 and 4 need it.
+	Range []float64
+	// FunctionType 0 fields
+	Size          []int     // number of samples per input
+	BitsPerSample int       // 1, 2, 4, 8, 12, 16, 24 or 32
+	Samples       []uint32  // prod(Size) × outputs values, first input varies fastest
+	Decode        []float64 // optional min/max pair per output
 	// FunctionType 2 fields
-	C0 [3]float64 // start RGB color (0..1)
-	C1 [3]float64 // end RGB color (0..1)
-	N  float64    // exponent; 1 for linear interpolation
+	C0 []float64 // start colour (0..1 per component)
+	C1 []float64 // end colour (0..1 per component)
+	N  float64   // exponent; 1 for linear interpolation
 	// FunctionType 3 fields
-	SubFunctions []Function   // each must be FunctionType 2
-	Bounds       []float64    // len = len(SubFunctions)-1
-	Encode       [][2]float64 // len = len(SubFunctions)
+	SubFunctions []Function // each with one input
+	Bounds       []float64  // len = len(SubFunctions)-1
+	// Encode has one pair per sub-function for type 3 and an optional pair
+	// per input for type 0.
+	Encode [][2]float64
+	// FunctionType 4 fields
+	Code string
 }
 
 // Shading describes a PDF Shading Dictionary (Section 8.7.4.5).
-// Only Type 2 (axial) is implemented; Type 3 (radial) is intentionally left
-// out until there is a need.
+// Type 2 (axial), type 3 (radial) and the mesh shadings 4 to 7 are
+// implemented.
 type Shading struct {
-	ShadingType int        // 2 = axial (linear)
-	ColorSpace  Name       // "/DeviceRGB"
-	Coords      [4]float64 // x1 y1 x2 y2 in pattern space
-	Function    Function
-	Extend      [2]bool // [extendStart extendEnd]
+	// 2 = axial (linear), 3 = radial, 4 = free-form triangle mesh,
+	// 5 = lattice triangle mesh, 6 = Coons patch mesh, 7 = tensor-product
+	// patch mesh
+	ShadingType int
+	ColorSpace  Name // "/DeviceRGB", "/DeviceCMYK", a Separation or DeviceN array
+	// Coords are x1 y1 x2 y2 in pattern space for axial shadings and
+	// x0 y0 r0 x1 y1 r1 (two circles) for radial shadings.
+	Coords []float64
+	// Function maps the parameter t to a colour. It is optional for mesh
+	// shadings: leave it zero to give the colours at the vertices directly.
+	Function Function
+	Extend   [2]bool // [extendStart extendEnd]
+	// Mesh shading fields. BitsPerCoordinate is 1, 2, 4, 8, 12, 16, 24 or
+	// 32, BitsPerComponent 1, 2, 4, 8, 12 or 16 and BitsPerFlag 2, 4 or 8
+	// (not used by type 5). Decode is xmin xmax ymin ymax followed by a
+	// min/max pair per colour component, or a single pair for t if there
+	// is a function. Coordinates and colours are packed into the stream
+	// relative to these ranges.
+	BitsPerCoordinate int
+	BitsPerComponent  int
+	BitsPerFlag       int
+	Decode            []float64
+	// Vertices are the vertices of types 4 and 5, VerticesPerRow the
+	// number of vertices in each row of a type 5 lattice.
+	Vertices       []MeshVertex
+	VerticesPerRow int
+	// Patches are the patches of types 6 and 7.
+	Patches []MeshPatch
+	// BBox is an optional clipping rectangle [llx lly urx ury] in the
+	// shading's coordinate space.
+	BBox []float64
+	// Background is the colour of the area outside the shading's geometry,
+	// one value per colour component. The sh operator ignores it.
+	Background []float64
+	// AntiAlias asks the viewer to smooth the shading.
+	AntiAlias bool
 }
 
 // ShadingPattern describes a PDF Pattern Dictionary of type 2 (Shading
@@ -56,39 +111,11 @@ type ShadingPattern struct {
 // objects too, since /Functions in a Type 3 Function dictionary is an array
 // of indirect references.
 func (pw *PDF) WriteShadingPattern(p ShadingPattern) (*Object, error) {
-	funcObj, err := pw.writeFunction(p.Shading.Function)
+	shadingObj, err := pw.writeShading(p.Shading)
 	if err != nil {
 		return nil, err
 	}
 
-	cs := p.Shading.ColorSpace
-	if cs == "" {
-		cs = "/DeviceRGB"
-	}
-	extendStart := "false"
-	if p.Shading.Extend[0] {
-		extendStart = "true"
-	}
-	extendEnd := "false"
-	if p.Shading.Extend[1] {
-		extendEnd = "true"
-	}
-	shadingObj := pw.NewObject()
-	shadingObj.Dictionary = Dict{
-		"ShadingType": fmt.Sprintf("%d", p.Shading.ShadingType),
-		"ColorSpace":  string(cs),
-		"Coords": fmt.Sprintf("[%s %s %s %s]",
-			fmtPDFFloat(p.Shading.Coords[0]),
-			fmtPDFFloat(p.Shading.Coords[1]),
-			fmtPDFFloat(p.Shading.Coords[2]),
-			fmtPDFFloat(p.Shading.Coords[3])),
-		"Function": funcObj.ObjectNumber.Ref(),
-		"Extend":   fmt.Sprintf("[%s %s]", extendStart, extendEnd),
-	}
-	if err := shadingObj.Save(); err != nil {
-		return nil, err
-	}
-
 	patternObj := pw.NewObject()
 	patternObj.Dictionary = Dict{
 		"Type":        "/Pattern",
@@ -105,22 +132,264 @@ func (pw *PDF) WriteShadingPattern(p ShadingPattern) (*Object, error) {
 	return patternObj, nil
 }
 
+// WriteShading writes the shading without a pattern and returns the shading
+// object. Add it to the Shadings of a page or to the /Shading resources of a
+// Form XObject and paint it with "/<name> sh", usually inside a clipping
+// path.
+func (pw *PDF) WriteShading(s Shading) (*Object, error) {
+	return pw.writeShading(s)
+}
+
+// writeShading writes the function and the shading dictionary and returns
+// the shading object.
+func (pw *PDF) writeShading(s Shading) (*Object, error) {
+	cs := s.ColorSpace
+	if cs == "" {
+		cs = "/DeviceRGB"
+	}
+	mesh := s.ShadingType >= 4 && s.ShadingType <= 7
+	switch {
+	case s.ShadingType == 2:
+		if len(s.Coords) != 4 {
+			return nil, fmt.Errorf("pdf: axial shading needs 4 coords, got %d", len(s.Coords))
+		}
+	case s.ShadingType == 3:
+		if len(s.Coords) != 6 {
+			return nil, fmt.Errorf("pdf: radial shading needs 6 coords, got %d", len(s.Coords))
+		}
+		if s.Coords[2] < 0 || s.Coords[5] < 0 {
+			return nil, fmt.Errorf("pdf: radial shading with a negative radius")
+		}
+	case mesh:
+	default:
+		return nil, fmt.Errorf("pdf: unsupported ShadingType %d", s.ShadingType)
+	}
+	// mesh shadings have colours at their vertices unless there is a
+	// function.
+	hasFunction := !mesh || !s.Function.isZero()
+	components := pw.colorSpaceComponents(cs)
+	fn := s.Function
+	if hasFunction {
+		if components > 0 {
+			fn = fn.withComponents(components)
+		}
+		outputs, err := fn.outputs()
+		if err != nil {
+			return nil, err
+		}
+		if fn.inputs() != 1 {
+			return nil, fmt.Errorf("pdf: shading function needs one input, got %d", fn.inputs())
+		}
+		if components > 0 && components != outputs {
+			return nil, fmt.Errorf("pdf: shading function has %d output components, colour space %s needs %d", outputs, cs, components)
+		}
+		components = outputs
+	}
+	if s.BBox != nil && len(s.BBox) != 4 {
+		return nil, fmt.Errorf("pdf: shading BBox needs 4 values, got %d", len(s.BBox))
+	}
+	if s.Background != nil && components > 0 && len(s.Background) != components {
+		return nil, fmt.Errorf("pdf: shading Background has %d components, want %d", len(s.Background), components)
+	}
+
+	d := Dict{
+		"ShadingType": fmt.Sprintf("%d", s.ShadingType),
+		"ColorSpace":  string(cs),
+	}
+	var data []byte
+	if mesh {
+		var err error
+		if data, err = s.meshData(d, components, hasFunction); err != nil {
+			return nil, err
+		}
+	} else {
+		extendStart := "false"
+		if s.Extend[0] {
+			extendStart = "true"
+		}
+		extendEnd := "false"
+		if s.Extend[1] {
+			extendEnd = "true"
+		}
+		d["Coords"] = numberArray(s.Coords)
+		d["Extend"] = fmt.Sprintf("[%s %s]", extendStart, extendEnd)
+	}
+	if hasFunction {
+		funcObj, err := pw.writeFunction(fn)
+		if err != nil {
+			return nil, err
+		}
+		d["Function"] = funcObj.ObjectNumber.Ref()
+	}
+	if s.BBox != nil {
+		d["BBox"] = numberArray(s.BBox)
+	}
+	if s.Background != nil {
+		d["Background"] = numberArray(s.Background)
+	}
+	if s.AntiAlias {
+		d["AntiAlias"] = "true"
+	}
+	shadingObj := pw.NewObject()
+	shadingObj.Dictionary = d
+	if mesh {
+		shadingObj.Data.Write(data)
+		shadingObj.ForceStream = true
+		shadingObj.SetCompression(9)
+	}
+	if err := shadingObj.Save(); err != nil {
+		return nil, err
+	}
+	return shadingObj, nil
+}
+
+// colorSpaceComponents returns the number of colour components of the
+// colour space, 0 if it is not known.
+func (pw *PDF) colorSpaceComponents(cs Name) int {
+	switch cs {
+	case "/DeviceGray":
+		return 1
+	case "/DeviceRGB":
+		return 3
+	case "/DeviceCMYK":
+		return 4
+	}
+	for _, sep := range pw.Colorspaces {
+		if string(cs) == sep.Obj.Ref() {
+			return 1
+		}
+	}
+	for _, c := range pw.colorSpaces {
+		if cs == c.Ref() {
+			return c.components
+		}
+	}
+	switch fields := strings.Fields(strings.NewReplacer("[", " [ ", "]", " ] ").Replace(string(cs))); {
+	case len(fields) > 1 && fields[0] == "[" && fields[1] == "/Separation":
+		return 1
+	case len(fields) > 3 && fields[0] == "[" && fields[1] == "/DeviceN" && fields[2] == "[":
+		n := 0
+		for _, f := range fields[3:] {
+			if f == "]" {
+				return n
+			}
+			n++
+		}
+	}
+	return 0
+}
+
+// domain returns the Domain of the function, [0 1] if it is not set.
+func (f Function) domain() []float64 {
+	if f.Domain == nil {
+		return []float64{0, 1}
+	}
+	return f.Domain
+}
+
+// inputs returns the number of input values of the function.
+func (f Function) inputs() int {
+	return len(f.domain()) / 2
+}
+
+// outputs returns the number of output values (colour components) of the
+// function.
+func (f Function) outputs() (int, error) {
+	switch f.FunctionType {
+	case 0, 4:
+		if err := checkIntervals("Range", f.Range); err != nil {
+			return 0, err
+		}
+		return len(f.Range) / 2, nil
+	case 2:
+		c0, c1 := f.c0(), f.c1()
+		if len(c0) != len(c1) {
+			return 0, fmt.Errorf("pdf: function C0 has %d components, C1 has %d", len(c0), len(c1))
+		}
+		return len(c0), nil
+	case 3:
+		n := 0
+		for i, sub := range f.SubFunctions {
+			if sub.inputs() != 1 {
+				return 0, fmt.Errorf("pdf: stitching function sub-function needs one input, got %d", sub.inputs())
+			}
+			m, err := sub.outputs()
+			if err != nil {
+				return 0, err
+			}
+			if i > 0 && m != n {
+				return 0, fmt.Errorf("pdf: stitching function sub-functions have %d and %d output components", n, m)
+			}
+			n = m
+		}
+		return n, nil
+	}
+	return 0, fmt.Errorf("pdf: unsupported FunctionType %d", f.FunctionType)
+}
+
+// withComponents returns a copy of the function in which nil C0 and C1 of
+// exponential functions, also below stitching functions, are n zeros and n
+// ones.
+func (f Function) withComponents(n int) Function {
+	switch f.FunctionType {
+	case 2:
+		if f.C0 == nil {
+			f.C0 = make([]float64, n)
+		}
+		if f.C1 == nil {
+			f.C1 = make([]float64, n)
+			for i := range f.C1 {
+				f.C1[i] = 1
+			}
+		}
+	case 3:
+		subs := make([]Function, len(f.SubFunctions))
+		for i, sub := range f.SubFunctions {
+			subs[i] = sub.withComponents(n)
+		}
+		f.SubFunctions = subs
+	}
+	return f
+}
+
+func (f Function) c0() []float64 {
+	if f.C0 == nil {
+		return []float64{0}
+	}
+	return f.C0
+}
+
+func (f Function) c1() []float64 {
+	if f.C1 == nil {
+		return []float64{1}
+	}
+	return f.C1
+}
+
 // writeFunction emits a single Function object and recurses into sub-
 // functions for the stitching case. Returns the indirect object so the
 // caller can stash its reference.
 func (pw *PDF) writeFunction(f Function) (*Object, error) {
+	if err := checkIntervals("Domain", f.domain()); err != nil {
+		return nil, err
+	}
+	if (f.FunctionType == 2 || f.FunctionType == 3) && f.inputs() != 1 {
+		return nil, fmt.Errorf("pdf: FunctionType %d needs one input, got %d", f.FunctionType, f.inputs())
+	}
 	switch f.FunctionType {
+	case 0:
+		return pw.writeSampledFunction(f)
 	case 2:
+		if _, err := f.outputs(); err != nil {
+			return nil, err
+		}
 		obj := pw.NewObject()
 		obj.Dictionary = Dict{
 			"FunctionType": "2",
-			"Domain": fmt.Sprintf("[%s %s]",
-				fmtPDFFloat(f.Domain[0]), fmtPDFFloat(f.Domain[1])),
-			"C0": fmt.Sprintf("[%s %s %s]",
-				fmtPDFFloat(f.C0[0]), fmtPDFFloat(f.C0[1]), fmtPDFFloat(f.C0[2])),
-			"C1": fmt.Sprintf("[%s %s %s]",
-				fmtPDFFloat(f.C1[0]), fmtPDFFloat(f.C1[1]), fmtPDFFloat(f.C1[2])),
-			"N": fmtPDFFloat(f.N),
+			"Domain":       numberArray(f.domain()),
+			"C0":           numberArray(f.c0()),
+			"C1":           numberArray(f.c1()),
+			"N":            fmtPDFFloat(f.N),
 		}
 		if err := obj.Save(); err != nil {
 			return nil, err
@@ -157,16 +426,17 @@ func (pw *PDF) writeFunction(f Function) (*Object, error) {
 		obj := pw.NewObject()
 		obj.Dictionary = Dict{
 			"FunctionType": "3",
-			"Domain": fmt.Sprintf("[%s %s]",
-				fmtPDFFloat(f.Domain[0]), fmtPDFFloat(f.Domain[1])),
-			"Functions": "[" + strings.Join(subRefs, " ") + "]",
-			"Bounds":    "[" + strings.Join(boundsStrs, " ") + "]",
-			"Encode":    "[" + strings.Join(encodeStrs, " ") + "]",
+			"Domain":       numberArray(f.domain()),
+			"Functions":    "[" + strings.Join(subRefs, " ") + "]",
+			"Bounds":       "[" + strings.Join(boundsStrs, " ") + "]",
+			"Encode":       "[" + strings.Join(encodeStrs, " ") + "]",
 		}
 		if err := obj.Save(); err != nil {
 			return nil, err
 		}
 		return obj, nil
+	case 4:
+		return pw.writeCalculatorFunction(f)
 	default:
 		return nil, fmt.Errorf("pdf: unsupported FunctionType %d", f.FunctionType)
 	}
@@ -2,6 +2,9 @@ package pdf
 
 import (
 	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
 	"math"
 	"strings"
 	"testing"
@@ -51,9 +54,9 @@ func TestWriteFunctionType2(t *testing.T) {
 	pw, buf := newTestPDF()
 	f := Function{
 		FunctionType: 2,
-		Domain:       [2]float64{0, 1},
-		C0:           [3]float64{1, 0, 0},
-		C1:           [3]float64{0, 0, 1},
+		Domain:       []float64{0, 1},
+		C0:           []float64{1, 0, 0},
+		C1:           []float64{0, 0, 1},
 		N:            1,
 	}
 	obj, err := pw.writeFunction(f)
@@ -84,10 +87,10 @@ func TestWriteFunctionType3Stitching(t *testing.T) {
 	pw, buf := newTestPDF()
 	f := Function{
 		FunctionType: 3,
-		Domain:       [2]float64{0, 1},
+		Domain:       []float64{0, 1},
 		SubFunctions: []Function{
-			{FunctionType: 2, Domain: [2]float64{0, 1}, C0: [3]float64{1, 0, 0}, C1: [3]float64{0, 1, 0}, N: 1},
-			{FunctionType: 2, Domain: [2]float64{0, 1}, C0: [3]float64{0, 1, 0}, C1: [3]float64{0, 0, 1}, N: 1},
+			{FunctionType: 2, Domain: []float64{0, 1}, C0: []float64{1, 0, 0}, C1: []float64{0, 1, 0}, N: 1},
+			{FunctionType: 2, Domain: []float64{0, 1}, C0: []float64{0, 1, 0}, C1: []float64{0, 0, 1}, N: 1},
 		},
 		Bounds: []float64{0.5},
 		Encode: [][2]float64{{0, 1}, {0, 1}},
@@ -130,7 +133,7 @@ func TestWriteFunctionErrors(t *testing.T) {
 	}{
 		{
 			"unsupported type",
-			Function{FunctionType: 4},
+			Function{FunctionType: 1},
 			"unsupported FunctionType",
 		},
 		{
@@ -185,12 +188,12 @@ func TestWriteShadingPattern2Stop(t *testing.T) {
 		Shading: Shading{
 			ShadingType: 2,
 			ColorSpace:  "/DeviceRGB",
-			Coords:      [4]float64{0, 0, 100, 0},
+			Coords:      []float64{0, 0, 100, 0},
 			Function: Function{
 				FunctionType: 2,
-				Domain:       [2]float64{0, 1},
-				C0:           [3]float64{1, 0, 0},
-				C1:           [3]float64{0, 0, 1},
+				Domain:       []float64{0, 1},
+				C0:           []float64{1, 0, 0},
+				C1:           []float64{0, 0, 1},
 				N:            1,
 			},
 		},
@@ -237,3 +240,298 @@ func TestWriteShadingPattern2Stop(t *testing.T) {
 		t.Errorf("returned Pattern object number = %d, want 3", pat.ObjectNumber)
 	}
 }
+
+func TestWriteShadingPatternRadial(t *testing.T) {
+	pw, buf := newTestPDF()
+	_, err := pw.WriteShadingPattern(ShadingPattern{
+		Shading: Shading{
+			ShadingType: 3,
+			ColorSpace:  "/DeviceCMYK",
+			Coords:      []float64{50, 50, 0, 50, 50, 40},
+			Function: Function{
+				FunctionType: 2,
+				Domain:       []float64{0, 1},
+				C0:           []float64{0, 0, 0, 0},
+				C1:           []float64{1, 0.5, 0, 0},
+				N:            1,
+			},
+			Extend: [2]bool{false, true},
+		},
+		Matrix: [6]float64{1, 0, 0, 1, 0, 0},
+	})
+	if err != nil {
+		t.Fatalf("WriteShadingPattern: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"/C0 [0 0 0 0]",
+		"/C1 [1 0.5 0 0]",
+		"/ShadingType 3",
+		"/ColorSpace /DeviceCMYK",
+		"/Coords [50 50 0 50 50 40]",
+		"/Extend [false true]",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q\nfull output:\n%s", want, out)
+		}
+	}
+}
+
+func TestWriteShadingColorComponents(t *testing.T) {
+	tests := []struct {
+		name       string
+		colorSpace Name
+		c0, c1     []float64
+	}{
+		{"gray defaults", "/DeviceGray", nil, nil},
+		{"separation", "[/Separation /Spot /DeviceCMYK 5 0 R]", []float64{0}, []float64{1}},
+		{"devicen", "[/DeviceN [/Cyan /Spot /Black] /DeviceCMYK 5 0 R]", []float64{0, 0, 0}, []float64{1, 0.5, 0}},
+		{"unknown named space", "/CS1", []float64{0, 0}, []float64{1, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pw, _ := newTestPDF()
+			_, err := pw.writeShading(Shading{
+				ShadingType: 2,
+				ColorSpace:  tt.colorSpace,
+				Coords:      []float64{0, 0, 100, 0},
+				Function:    Function{FunctionType: 2, Domain: []float64{0, 1}, C0: tt.c0, C1: tt.c1, N: 1},
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestWriteShadingDefaultColors(t *testing.T) {
+	pw, buf := newTestPDF()
+	stitched := Function{FunctionType: 2, N: 1}
+	for _, fn := range []Function{
+		{FunctionType: 2, N: 1},
+		{FunctionType: 3, SubFunctions: []Function{stitched, stitched}, Bounds: []float64{0.5}, Encode: [][2]float64{{0, 1}, {0, 1}}},
+	} {
+		if _, err := pw.writeShading(Shading{ShadingType: 2, Coords: []float64{0, 0, 100, 0}, Function: fn}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := strings.Count(buf.String(), "/C0 [0 0 0]"); got != 3 {
+		t.Errorf("got %d functions with C0 [0 0 0], want 3", got)
+	}
+	if got := strings.Count(buf.String(), "/C1 [1 1 1]"); got != 3 {
+		t.Errorf("got %d functions with C1 [1 1 1], want 3", got)
+	}
+}
+
+func TestWriteShadingErrors(t *testing.T) {
+	rgb := Function{FunctionType: 2, Domain: []float64{0, 1}, C0: []float64{1, 0, 0}, C1: []float64{0, 0, 1}, N: 1}
+	tests := []struct {
+		name string
+		s    Shading
+		want string
+	}{
+		{"axial coords", Shading{ShadingType: 2, Coords: []float64{0, 0, 1, 1, 2, 2}, Function: rgb}, "4 coords"},
+		{"radial coords", Shading{ShadingType: 3, Coords: []float64{0, 0, 1, 1}, Function: rgb}, "6 coords"},
+		{"negative radius", Shading{ShadingType: 3, Coords: []float64{0, 0, -1, 0, 0, 5}, Function: rgb}, "negative radius"},
+		{"unsupported type", Shading{ShadingType: 1, Function: rgb}, "unsupported ShadingType"},
+		{"bbox", Shading{ShadingType: 2, Coords: []float64{0, 0, 1, 1}, Function: rgb, BBox: []float64{0, 0, 1}}, "BBox needs 4"},
+		{"background", Shading{ShadingType: 2, Coords: []float64{0, 0, 1, 1}, Function: rgb, Background: []float64{1}}, "Background has 1"},
+		{"cmyk with rgb function", Shading{ShadingType: 2, ColorSpace: "/DeviceCMYK", Coords: []float64{0, 0, 1, 1}, Function: rgb}, "needs 4"},
+		{
+			"c0 c1 mismatch",
+			Shading{ShadingType: 2, ColorSpace: "/DeviceGray", Coords: []float64{0, 0, 1, 1}, Function: Function{FunctionType: 2, C0: []float64{0}, C1: []float64{1, 1, 1}, N: 1}},
+			"C0 has 1 components",
+		},
+		{
+			"stitching mismatch",
+			Shading{ShadingType: 2, Coords: []float64{0, 0, 1, 1}, Function: Function{
+				FunctionType: 3,
+				SubFunctions: []Function{rgb, {FunctionType: 2, C0: []float64{0}, C1: []float64{1}, N: 1}},
+				Bounds:       []float64{0.5},
+				Encode:       [][2]float64{{0, 1}, {0, 1}},
+			}},
+			"sub-functions have 3 and 1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pw, _ := newTestPDF()
+			_, err := pw.writeShading(tt.s)
+			if err == nil {
+				t.Fatalf("expected error containing %q, got nil", tt.want)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error %q does not contain %q", err.Error(), tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteFunctionType0Sampled(t *testing.T) {
+	pw, buf := newTestPDF()
+	f := Function{
+		FunctionType:  0,
+		Domain:        []float64{0, 1},
+		Range:         []float64{0, 1, 0, 1, 0, 1, 0, 1},
+		Size:          []int{3},
+		BitsPerSample: 4,
+		Samples:       []uint32{0, 0, 0, 0, 15, 8, 0, 0, 15, 15, 15, 1},
+		Decode:        []float64{0, 1, 0, 1, 0, 1, 0, 1},
+	}
+	if _, err := pw.writeFunction(f); err != nil {
+		t.Fatalf("writeFunction Type 0: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"/FunctionType 0",
+		"/Range [0 1 0 1 0 1 0 1]",
+		"/Size [3]",
+		"/BitsPerSample 4",
+		"/Decode [0 1 0 1 0 1 0 1]",
+		"/Filter /FlateDecode",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Type 0 output missing %q\nfull output:\n%s", want, out)
+		}
+	}
+	start := strings.Index(out, "stream\n") + len("stream\n")
+	end := strings.Index(out, "\nendstream")
+	zr, err := zlib.NewReader(strings.NewReader(out[start:end]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	samples, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []byte{0x00, 0x00, 0xf8, 0x00, 0xff, 0xf1}; !bytes.Equal(samples, want) {
+		t.Errorf("samples = % x, want % x", samples, want)
+	}
+}
+
+func TestWriteFunctionType4Calculator(t *testing.T) {
+	pw, buf := newTestPDF()
+	f := Function{
+		FunctionType: 4,
+		Range:        []float64{0, 1, 0, 1, 0, 1, 0, 1},
+		Code:         "{0 0 3 -1 roll dup 0.5 gt {pop 1} if 0}",
+	}
+	if _, err := pw.writeFunction(f); err != nil {
+		t.Fatalf("writeFunction Type 4: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"/FunctionType 4",
+		"/Domain [0 1]",
+		"/Range [0 1 0 1 0 1 0 1]",
+		"stream\n{ 0 0 3 -1 roll dup 0.5 gt { pop 1 } if 0 }\nendstream",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Type 4 output missing %q\nfull output:\n%s", want, out)
+		}
+	}
+}
+
+func TestCalculatorCode(t *testing.T) {
+	for _, code := range []string{
+		"{ 1 exch sub }",
+		"{ dup 0.5 lt { 2 mul } { 1 sub 2 mul } ifelse }",
+		"{ 1.5e2 -3 .5 add add }",
+	} {
+		if _, err := checkCalculatorCode(code); err != nil {
+			t.Errorf("checkCalculatorCode(%q): %v", code, err)
+		}
+	}
+	for _, code := range []string{
+		"",
+		"1 exch sub",
+		"{ 1 exch sub",
+		"{ 1 exch sub } pop",
+		"{ 1 exch moveto }",
+		"{ Inf }",
+		"{ { pop } }",
+		"{ dup { pop } ifelse }",
+		"{ { pop } { pop } if }",
+		"{ { pop } 1 if }",
+	} {
+		if _, err := checkCalculatorCode(code); err == nil {
+			t.Errorf("checkCalculatorCode(%q): expected an error", code)
+		}
+	}
+}
+
+func TestWriteFunctionType0Type4Errors(t *testing.T) {
+	sampled := Function{FunctionType: 0, Range: []float64{0, 1}, Size: []int{2}, BitsPerSample: 8, Samples: []uint32{0, 255}}
+	tests := []struct {
+		name   string
+		modify func(f *Function)
+		want   string
+	}{
+		{"odd range", func(f *Function) { f.Range = []float64{0, 1, 0} }, "Range needs min/max pairs"},
+		{"missing range", func(f *Function) { f.Range = nil }, "Range needs min/max pairs"},
+		{"reversed domain", func(f *Function) { f.Domain = []float64{1, 0} }, "Domain min 1"},
+		{"size count", func(f *Function) { f.Size = []int{2, 2} }, "needs 1 Size values"},
+		{"size zero", func(f *Function) { f.Size = []int{0} }, "not positive"},
+		{"bits per sample", func(f *Function) { f.BitsPerSample = 3 }, "BitsPerSample 3"},
+		{"sample count", func(f *Function) { f.Samples = []uint32{0} }, "needs 2 samples"},
+		{"sample too large", func(f *Function) { f.Samples = []uint32{0, 256} }, "does not fit"},
+		{"decode", func(f *Function) { f.Decode = []float64{0, 1, 0, 1} }, "needs 2 Decode values"},
+		{"encode", func(f *Function) { f.Encode = [][2]float64{{0, 1}, {0, 1}} }, "encode (2)"},
+		{"calculator syntax", func(f *Function) { *f = Function{FunctionType: 4, Range: []float64{0, 1}, Code: "{ 1 exch"} }, "missing closing brace"},
+		{"calculator range", func(f *Function) { *f = Function{FunctionType: 4, Code: "{ }"} }, "Range"},
+		{"two input axial", func(f *Function) { *f = Function{FunctionType: 2, Domain: []float64{0, 1, 0, 1}} }, "needs one input"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pw, _ := newTestPDF()
+			f := sampled
+			tt.modify(&f)
+			_, err := pw.writeFunction(f)
+			if err == nil {
+				t.Fatalf("expected error containing %q, got nil", tt.want)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error %q does not contain %q", err.Error(), tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteShadingResource(t *testing.T) {
+	pw, buf := newTestPDF()
+	sh, err := pw.WriteShading(Shading{
+		ShadingType: 3,
+		ColorSpace:  "/DeviceGray",
+		Coords:      []float64{50, 50, 0, 50, 50, 50},
+		Function:    Function{FunctionType: 2, N: 1},
+		BBox:        []float64{0, 0, 100, 100},
+		Background:  []float64{0.5},
+		AntiAlias:   true,
+	})
+	if err != nil {
+		t.Fatalf("WriteShading: %v", err)
+	}
+	content := pw.NewObject()
+	content.Data.WriteString("q 0 0 100 100 re W n /sh1 sh Q")
+	page := pw.AddPage(content, 0)
+	page.Shadings = map[Name]*Object{"sh1": sh}
+	if err := pw.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"/ShadingType 3",
+		"/BBox [0 0 100 100]",
+		"/Background [0.5]",
+		"/AntiAlias true",
+		"/Shading <<",
+		fmt.Sprintf("/sh1 %s", sh.ObjectNumber.Ref()),
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q", want)
+		}
+	}
+	if strings.Contains(out, "/Type /Pattern") {
+		t.Error("standalone shading should not write a pattern")
+	}
+}
@@ -0,0 +1,403 @@
+package pdf
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+)
+
+// defaultSignatureSize is the number of bytes reserved for the CMS signature
+// container if Signature.Size is not set.
+const defaultSignatureSize = 16384
+
+// Signature describes a PAdES baseline B-B signature that is applied to the
+// document when it is finished. The signature is a detached CMS container
+// (/SubFilter /ETSI.CAdES.detached) computed with Signer over the whole file
+// except the /Contents string.
+type Signature struct {
+	Signer crypto.Signer
+	// Certificates holds the signing certificate first, followed by the
+	// intermediate certificates that should be embedded.
+	Certificates []*x509.Certificate
+	FieldName    string     // name of the signature field, defaults to "Signature1"
+	Name         string     // name of the person or authority signing
+	Reason       string     // reason for the signing, such as "Invoice approval"
+	Location     string     // physical location of the signing
+	ContactInfo  string     // information to contact the signer
+	SigningTime  time.Time  // written as /M, defaults to time.Now()
+	Rect         [4]float64 // widget rectangle; all zero for an invisible signature
+	Size         int        // bytes reserved for the CMS container, defaults to 16384
+
+	sigObj     Objectnumber
+	widget     Objectnumber
+	appearance Objectnumber
+	contents   int64 // file offset of the /Contents hex string
+	byteRange  int64 // file offset of the /ByteRange array
+}
+
+// signatureTarget is an output that can be patched after it is written, such
+// as an *os.File.
+type signatureTarget interface {
+	io.ReaderAt
+	io.WriterAt
+}
+
+// byteSliceTarget patches a PDF held in memory.
+type byteSliceTarget []byte
+
+func (b byteSliceTarget) ReadAt(p []byte, off int64) (int, error) {
+	if off >= int64(len(b)) {
+		return 0, io.EOF
+	}
+	n := copy(p, b[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (b byteSliceTarget) WriteAt(p []byte, off int64) (int, error) {
+	if off+int64(len(p)) > int64(len(b)) {
+		return 0, errors.New("pdf: write beyond end of buffer")
+	}
+	return copy(b[off:], p), nil
+}
+
+// AddSignature reserves a signature field with a widget annotation on page
+// and signs the document when Finish is called. Only one signature can be
+// applied per document. If the form has a signature field (FieldSignature)
+// named sig.FieldName, that field is signed instead and page may be nil.
+//
+// The byte range of the signature is only known once the whole file is
+// written, so the output must be patchable: either it implements io.ReaderAt
+// and io.WriterAt (like *os.File) and starts at offset 0, or it is a
+// *bytes.Buffer, or AddSignature is called before anything is written. In
+// the last case the PDF is buffered in memory and copied to the output at
+// the end of Finish.
+func (pw *PDF) AddSignature(page *Page, sig *Signature) error {
+	if pw.signature != nil {
+		return errors.New("pdf: only one signature per document is supported")
+	}
+	if sig.Signer == nil || len(sig.Certificates) == 0 {
+		return errors.New("pdf: signature needs a signer and a certificate")
+	}
+	if sig.FieldName == "" {
+		sig.FieldName = "Signature1"
+	}
+	field := pw.findFormField(sig.FieldName)
+	if field != nil && field.Type != FieldSignature {
+		return fmt.Errorf("pdf: form field %q is not a signature field", sig.FieldName)
+	}
+	if page == nil && field == nil {
+		return errors.New("pdf: signature needs a page")
+	}
+	switch pw.outfile.(type) {
+	case signatureTarget, *bytes.Buffer:
+	default:
+		if pw.pos > 0 {
+			return errors.New("pdf: output is not seekable, call AddSignature before writing any object")
+		}
+		pw.signatureOut = pw.outfile
+		pw.outfile = &bytes.Buffer{}
+	}
+	if sig.Size == 0 {
+		sig.Size = defaultSignatureSize
+	}
+	if sig.SigningTime.IsZero() {
+		sig.SigningTime = time.Now()
+	}
+	sig.sigObj = pw.NextObject()
+	pw.signature = sig
+
+	if field != nil {
+		// sign an existing signature field, which already has its widget
+		// and appearance
+		sig.widget = field.objnum
+		sig.Rect = field.Rect
+		field.dict["V"] = sig.sigObj.Ref()
+		return nil
+	}
+	sig.widget = pw.NextObject()
+	sig.appearance = pw.NextObject()
+	page.Annotations = append(page.Annotations, Annotation{
+		Subtype:      "Widget",
+		Rect:         sig.Rect,
+		Objectnumber: sig.widget,
+		Dictionary: Dict{
+			"FT": "/Sig",
+			"T":  stringToPDF(sig.FieldName),
+			"V":  sig.sigObj.Ref(),
+			"F":  "132", // print, locked
+			"P":  page.Objnum.Ref(),
+			"AP": "<< /N " + sig.appearance.Ref() + " >>",
+		},
+	})
+	return nil
+}
+
+// writeSignatureAppearance writes the (empty) normal appearance of the
+// signature widget, which PDF/A requires even for invisible signatures.
+func (pw *PDF) writeSignatureAppearance(sig *Signature) error {
+	if sig.appearance == 0 {
+		// the signature field has its own appearance
+		return nil
+	}
+	ap := pw.NewObjectWithNumber(sig.appearance)
+	ap.Dictionary = Dict{
+		"Type":    "/XObject",
+		"Subtype": "/Form",
+		"BBox": fmt.Sprintf("[0 0 %s %s]",
+			FloatToPoint(sig.Rect[2]-sig.Rect[0]), FloatToPoint(sig.Rect[3]-sig.Rect[1])),
+	}
+	ap.ForceStream = true
+	return ap.Save()
+}
+
+const byteRangePlaceholder = "[0 0000000000 0000000000 0000000000]"
+
+// writeSignatureDict writes the signature dictionary with placeholders for
+// /ByteRange and /Contents and records their file offsets. The dictionary is
+// written verbatim: /Contents must not be encrypted, so the other strings are
+// encrypted here.
+func (pw *PDF) writeSignatureDict(sig *Signature) error {
+	str := func(s string) string {
+		enc := stringToPDF(s)
+		if pw.security != nil {
+			b, _ := pw.security.encryptStrings(sig.sigObj, []byte(enc))
+			enc = string(b)
+		}
+		return enc
+	}
+	contentsPlaceholder := "<" + strings.Repeat("0", 2*sig.Size) + ">"
+	d := Dict{
+		"Type":      "/Sig",
+		"Filter":    "/Adobe.PPKLite",
+		"SubFilter": "/ETSI.CAdES.detached",
+		"ByteRange": byteRangePlaceholder,
+		"Contents":  contentsPlaceholder,
+		"M":         str(strings.Trim(pdfDate(sig.SigningTime), "()")),
+	}
+	for k, v := range map[Name]string{"Name": sig.Name, "Reason": sig.Reason, "Location": sig.Location, "ContactInfo": sig.ContactInfo} {
+		if v != "" {
+			d[k] = str(v)
+		}
+	}
+	body := hashToString(d, 0)
+	obj := pw.NewObjectWithNumber(sig.sigObj)
+	obj.Raw = true
+	obj.unencrypted = true
+	obj.Data = bytes.NewBufferString(body)
+	if err := obj.Save(); err != nil {
+		return err
+	}
+	start := pw.objectlocations[sig.sigObj] + int64(len(sig.sigObj.String()+" 0 obj\n"))
+	sig.contents = start + int64(strings.Index(body, contentsPlaceholder))
+	sig.byteRange = start + int64(strings.Index(body, byteRangePlaceholder))
+	return nil
+}
+
+// finishSignature fills in /ByteRange and /Contents once the complete file
+// has been written.
+func (pw *PDF) finishSignature() error {
+	sig := pw.signature
+	var target signatureTarget
+	switch out := pw.outfile.(type) {
+	case *bytes.Buffer:
+		target = byteSliceTarget(out.Bytes())
+	case signatureTarget:
+		target = out
+	}
+
+	contentsEnd := sig.contents + int64(2*sig.Size+2)
+	br := fmt.Sprintf("[0 %d %d %d]", sig.contents, contentsEnd, pw.pos-contentsEnd)
+	if len(br) > len(byteRangePlaceholder) {
+		return errors.New("pdf: signature byte range does not fit")
+	}
+	br += strings.Repeat(" ", len(byteRangePlaceholder)-len(br))
+	if _, err := target.WriteAt([]byte(br), sig.byteRange); err != nil {
+		return err
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(h, io.NewSectionReader(target, 0, sig.contents)); err != nil {
+		return err
+	}
+	if _, err := io.Copy(h, io.NewSectionReader(target, contentsEnd, pw.pos-contentsEnd)); err != nil {
+		return err
+	}
+	cms, err := buildCMSSignature(h.Sum(nil), sig.Signer, sig.Certificates)
+	if err != nil {
+		return err
+	}
+	if len(cms) > sig.Size {
+		return fmt.Errorf("pdf: signature needs %d bytes, only %d reserved", len(cms), sig.Size)
+	}
+	_, err = target.WriteAt([]byte(hex.EncodeToString(cms)), sig.contents+1)
+	return err
+}
+
+var (
+	oidData                 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidSignedData           = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidAttrContentType      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidAttrMessageDigest    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	oidAttrSigningCertV2    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 2, 47}
+	oidSHA256               = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidRSAEncryption        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidECDSAWithSHA256      = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+	asn1Null                = asn1.RawValue{Tag: asn1.TagNull}
+	errUnsupportedSignerKey = errors.New("pdf: only RSA and ECDSA signing keys are supported")
+)
+
+type algorithmIdentifier struct {
+	Algorithm  asn1.ObjectIdentifier
+	Parameters asn1.RawValue `asn1:"optional"`
+}
+
+type cmsAttribute struct {
+	Type   asn1.ObjectIdentifier
+	Values asn1.RawValue // SET OF
+}
+
+type issuerAndSerialNumber struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+type signerInfo struct {
+	Version            int
+	SID                issuerAndSerialNumber
+	DigestAlgorithm    algorithmIdentifier
+	SignedAttrs        asn1.RawValue
+	SignatureAlgorithm algorithmIdentifier
+	Signature          []byte
+}
+
+type encapsulatedContentInfo struct {
+	EContentType asn1.ObjectIdentifier
+}
+
+type signedData struct {
+	Version          int
+	DigestAlgorithms []algorithmIdentifier `asn1:"set"`
+	EncapContentInfo encapsulatedContentInfo
+	Certificates     asn1.RawValue
+	SignerInfos      []signerInfo `asn1:"set"`
+}
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue
+}
+
+type essCertIDv2 struct {
+	CertHash []byte
+}
+
+type signingCertificateV2 struct {
+	Certs []essCertIDv2
+}
+
+// asn1Set wraps the DER encoded elements in a SET, sorted as DER requires.
+func asn1Set(elements ...[]byte) asn1.RawValue {
+	slices.SortFunc(elements, bytes.Compare)
+	return asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: bytes.Join(elements, nil)}
+}
+
+func newCMSAttribute(typ asn1.ObjectIdentifier, value any) ([]byte, error) {
+	v, err := asn1.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(cmsAttribute{Type: typ, Values: asn1Set(v)})
+}
+
+// buildCMSSignature returns a DER encoded detached CMS SignedData container
+// (RFC 5652) for the SHA-256 digest of the signed byte ranges, with the
+// signed attributes PAdES B-B requires.
+func buildCMSSignature(digest []byte, signer crypto.Signer, certs []*x509.Certificate) ([]byte, error) {
+	var sigAlg algorithmIdentifier
+	switch signer.Public().(type) {
+	case *rsa.PublicKey:
+		sigAlg = algorithmIdentifier{Algorithm: oidRSAEncryption, Parameters: asn1Null}
+	case *ecdsa.PublicKey:
+		sigAlg = algorithmIdentifier{Algorithm: oidECDSAWithSHA256}
+	default:
+		return nil, errUnsupportedSignerKey
+	}
+	cert := certs[0]
+	certHash := sha256.Sum256(cert.Raw)
+
+	var attrs [][]byte
+	for _, a := range []struct {
+		typ   asn1.ObjectIdentifier
+		value any
+	}{
+		{oidAttrContentType, oidData},
+		{oidAttrMessageDigest, digest},
+		{oidAttrSigningCertV2, signingCertificateV2{Certs: []essCertIDv2{{CertHash: certHash[:]}}}},
+	} {
+		attr, err := newCMSAttribute(a.typ, a.value)
+		if err != nil {
+			return nil, err
+		}
+		attrs = append(attrs, attr)
+	}
+	// The signature is computed over the DER encoding of the attributes as
+	// a SET; inside SignerInfo they are stored with an implicit [0] tag.
+	signedAttrs := asn1Set(attrs...)
+	signedAttrsDER, err := asn1.Marshal(signedAttrs)
+	if err != nil {
+		return nil, err
+	}
+	attrsDigest := sha256.Sum256(signedAttrsDER)
+	signature, err := signer.Sign(rand.Reader, attrsDigest[:], crypto.SHA256)
+	if err != nil {
+		return nil, err
+	}
+	signedAttrs.Class = asn1.ClassContextSpecific
+	signedAttrs.Tag = 0
+
+	var rawCerts []byte
+	for _, c := range certs {
+		rawCerts = append(rawCerts, c.Raw...)
+	}
+	sd := signedData{
+		Version:          1,
+		DigestAlgorithms: []algorithmIdentifier{{Algorithm: oidSHA256}},
+		EncapContentInfo: encapsulatedContentInfo{EContentType: oidData},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: rawCerts},
+		SignerInfos: []signerInfo{{
+			Version: 1,
+			SID: issuerAndSerialNumber{
+				Issuer:       asn1.RawValue{FullBytes: cert.RawIssuer},
+				SerialNumber: cert.SerialNumber,
+			},
+			DigestAlgorithm:    algorithmIdentifier{Algorithm: oidSHA256},
+			SignedAttrs:        signedAttrs,
+			SignatureAlgorithm: sigAlg,
+			Signature:          signature,
+		}},
+	}
+	sdDER, err := asn1.Marshal(sd)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(contentInfo{
+		ContentType: oidSignedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: sdDER},
+	})
+}
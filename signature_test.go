@@ -0,0 +1,209 @@
+package pdf
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func selfSignedCertificate(t *testing.T, key crypto.Signer) *x509.Certificate {
+	t.Helper()
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(4711),
+		Subject:      pkix.Name{CommonName: "baseline-pdf test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+// nonSeekableWriter hides everything but Write of the underlying buffer.
+type nonSeekableWriter struct{ w io.Writer }
+
+func (n nonSeekableWriter) Write(p []byte) (int, error) { return n.w.Write(p) }
+
+func writeSignedTestPDF(t *testing.T, out io.Writer, key crypto.Signer, enc *Encryption) {
+	t.Helper()
+	pw := NewPDFWriter(out)
+	if enc != nil {
+		if err := pw.SetEncryption(enc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	content := pw.NewObject()
+	content.Data.WriteString("0 0 m 100 100 l S")
+	page := pw.AddPage(content, 0)
+	err := pw.AddSignature(page, &Signature{
+		Signer:       key,
+		Certificates: []*x509.Certificate{selfSignedCertificate(t, key)},
+		Name:         "Jane Doe",
+		Reason:       "Invoice approval",
+		Rect:         [4]float64{10, 10, 110, 40},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pw.Finish(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// verifySignedPDF checks the byte range and the CMS container of a signed
+// PDF the way a validator would.
+func verifySignedPDF(t *testing.T, pdf []byte) {
+	t.Helper()
+	m := regexp.MustCompile(`/ByteRange \[0 (\d+) (\d+) (\d+) *\]`).FindSubmatch(pdf)
+	if m == nil {
+		t.Fatal("no /ByteRange found")
+	}
+	var br [3]int
+	for i := range 3 {
+		br[i], _ = strconv.Atoi(string(m[i+1]))
+	}
+	if br[1]+br[2] != len(pdf) {
+		t.Fatalf("byte range %v does not cover the file of %d bytes", br, len(pdf))
+	}
+	if pdf[br[0]] != '<' || pdf[br[1]-1] != '>' {
+		t.Fatalf("byte range gap does not enclose the /Contents string")
+	}
+	digest := sha256.New()
+	digest.Write(pdf[:br[0]])
+	digest.Write(pdf[br[1]:])
+
+	// the container is followed by the zero padding of the placeholder
+	der, err := hex.DecodeString(string(pdf[br[0]+1 : br[1]-1]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ci contentInfo
+	if _, err := asn1.Unmarshal(der, &ci); err != nil {
+		t.Fatalf("parse content info: %v", err)
+	}
+	if !ci.ContentType.Equal(oidSignedData) {
+		t.Fatalf("content type %v", ci.ContentType)
+	}
+	var sd signedData
+	if _, err := asn1.Unmarshal(ci.Content.Bytes, &sd); err != nil {
+		t.Fatalf("parse signed data: %v", err)
+	}
+	cert, err := x509.ParseCertificate(sd.Certificates.Bytes)
+	if err != nil {
+		t.Fatalf("parse certificate: %v", err)
+	}
+	si := sd.SignerInfos[0]
+	if si.SID.SerialNumber.Cmp(cert.SerialNumber) != 0 {
+		t.Error("signer info does not identify the certificate")
+	}
+
+	signedAttrs := bytes.Clone(si.SignedAttrs.FullBytes)
+	signedAttrs[0] = 0x31 // SET instead of [0] IMPLICIT
+	var attrs []cmsAttribute
+	if _, err := asn1.UnmarshalWithParams(signedAttrs, &attrs, "set"); err != nil {
+		t.Fatalf("parse signed attributes: %v", err)
+	}
+	var foundDigest, foundSigningCert bool
+	for _, a := range attrs {
+		switch {
+		case a.Type.Equal(oidAttrMessageDigest):
+			var md []byte
+			asn1.Unmarshal(a.Values.Bytes, &md)
+			if !bytes.Equal(md, digest.Sum(nil)) {
+				t.Error("message digest does not match the byte range")
+			}
+			foundDigest = true
+		case a.Type.Equal(oidAttrSigningCertV2):
+			foundSigningCert = true
+		}
+	}
+	if !foundDigest || !foundSigningCert {
+		t.Errorf("missing signed attributes: digest %t, signing certificate %t", foundDigest, foundSigningCert)
+	}
+	algo := x509.SHA256WithRSA
+	if _, ok := cert.PublicKey.(*ecdsa.PublicKey); ok {
+		algo = x509.ECDSAWithSHA256
+	}
+	if err := cert.CheckSignature(algo, signedAttrs, si.Signature); err != nil {
+		t.Errorf("signature does not verify: %v", err)
+	}
+}
+
+func TestSignatureECDSA(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	var buf bytes.Buffer
+	writeSignedTestPDF(t, &buf, key, nil)
+	out := buf.Bytes()
+	for _, want := range []string{"/SubFilter /ETSI.CAdES.detached", "/FT /Sig", "/SigFlags 3", "/Reason (Invoice approval)"} {
+		if !bytes.Contains(out, []byte(want)) {
+			t.Errorf("output missing %q", want)
+		}
+	}
+	verifySignedPDF(t, out)
+}
+
+func TestSignatureRSAFile(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fn := filepath.Join(t.TempDir(), "signed.pdf")
+	f, err := os.Create(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeSignedTestPDF(t, f, key, nil)
+	f.Close()
+	out, err := os.ReadFile(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifySignedPDF(t, out)
+}
+
+func TestSignatureBufferedEncrypted(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	var buf bytes.Buffer
+	writeSignedTestPDF(t, nonSeekableWriter{&buf}, key, &Encryption{Permissions: PermissionPrint})
+	out := buf.Bytes()
+	if bytes.Contains(out, []byte("Invoice approval")) {
+		t.Error("signature reason not encrypted")
+	}
+	verifySignedPDF(t, out)
+}
+
+func TestSignatureNotSeekableAfterWrite(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	pw := NewPDFWriter(nonSeekableWriter{io.Discard})
+	content := pw.NewObject()
+	content.Data.WriteString("x")
+	content.Save()
+	page := pw.AddPage(content, 0)
+	err := pw.AddSignature(page, &Signature{Signer: key, Certificates: []*x509.Certificate{selfSignedCertificate(t, key)}})
+	if err == nil {
+		t.Error("expected an error for a non-seekable output that is already written to")
+	}
+}
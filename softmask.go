@@ -0,0 +1,74 @@
+package pdf
+
+import (
+	"fmt"
+	"slices"
+)
+
+// SoftMask is a soft-mask dictionary (Section 11.6.5.2 of ISO 32000-1). Write
+// it with PDF.WriteSoftMask and set it as the SoftMask of an ExtGState.
+type SoftMask struct {
+	// Subtype is Luminosity (the mask values are the luminosity of the
+	// group) or Alpha (the mask values are the group's opacity).
+	Subtype Name
+	// Group is the transparency group which is painted to compute the mask.
+	// Its Group must be set, with a ColorSpace for luminosity masks.
+	Group *FormXObject
+	// BackdropColor is the colour of the backdrop the group is composited
+	// on for luminosity masks, one value per component of the group's
+	// colour space. Nil means black.
+	BackdropColor []float64
+	// TransferFunction maps the computed mask values to opacities. It has
+	// one input and one output. Nil means the identity.
+	TransferFunction *Function
+}
+
+// WriteSoftMask writes the soft-mask dictionary and returns its object so
+// callers can use its number as the SoftMask of an ExtGState.
+func (pw *PDF) WriteSoftMask(sm SoftMask) (*Object, error) {
+	if !slices.Contains([]Name{"Luminosity", "Alpha"}, sm.Subtype) {
+		return nil, fmt.Errorf("pdf: soft mask subtype %q is not Luminosity or Alpha", sm.Subtype)
+	}
+	if sm.Group == nil || sm.Group.Group == nil {
+		return nil, fmt.Errorf("pdf: soft mask needs a Form XObject with a transparency group")
+	}
+	d := Dict{
+		"Type": "/Mask",
+		"S":    sm.Subtype.String(),
+		"G":    sm.Group.Objnum.Ref(),
+	}
+	if sm.Subtype == "Luminosity" {
+		cs := sm.Group.Group.ColorSpace
+		if cs == "" {
+			return nil, fmt.Errorf("pdf: luminosity soft mask needs a group colour space")
+		}
+		if n := pw.colorSpaceComponents(cs); sm.BackdropColor != nil && n > 0 && len(sm.BackdropColor) != n {
+			return nil, fmt.Errorf("pdf: soft mask backdrop colour has %d components, colour space %s needs %d", len(sm.BackdropColor), cs, n)
+		}
+	} else if sm.BackdropColor != nil {
+		return nil, fmt.Errorf("pdf: alpha soft mask has no backdrop colour")
+	}
+	if sm.BackdropColor != nil {
+		d["BC"] = numberArray(sm.BackdropColor)
+	}
+	if sm.TransferFunction != nil {
+		outputs, err := sm.TransferFunction.outputs()
+		if err != nil {
+			return nil, err
+		}
+		if sm.TransferFunction.inputs() != 1 || outputs != 1 {
+			return nil, fmt.Errorf("pdf: soft mask transfer function needs one input and one output")
+		}
+		fn, err := pw.writeFunction(*sm.TransferFunction)
+		if err != nil {
+			return nil, err
+		}
+		d["TR"] = fn.ObjectNumber.Ref()
+	}
+	obj := pw.NewObject()
+	obj.Dict(d)
+	if err := obj.Save(); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
@@ -0,0 +1,96 @@
+package pdf
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSoftMask(t *testing.T) {
+	pw, buf := newTestPDF()
+	mask := pw.NewFormXObject([4]float64{0, 0, 100, 100})
+	mask.Group = &TransparencyGroup{ColorSpace: "/DeviceGray"}
+	mask.Data.WriteString("/sh1 sh")
+	sh, err := pw.WriteShading(Shading{
+		ShadingType: 2,
+		ColorSpace:  "/DeviceGray",
+		Coords:      []float64{0, 0, 100, 0},
+		Function:    Function{FunctionType: 2, N: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mask.Resources.Shadings = map[Name]*Object{"sh1": sh}
+	lum, err := pw.WriteSoftMask(SoftMask{
+		Subtype:          "Luminosity",
+		Group:            mask,
+		BackdropColor:    []float64{0},
+		TransferFunction: &Function{FunctionType: 4, Range: []float64{0, 1}, Code: "{ 1 exch sub }"},
+	})
+	if err != nil {
+		t.Fatalf("WriteSoftMask: %v", err)
+	}
+	alpha, err := pw.WriteSoftMask(SoftMask{Subtype: "Alpha", Group: mask})
+	if err != nil {
+		t.Fatalf("WriteSoftMask: %v", err)
+	}
+	content := pw.NewObject()
+	content.Data.WriteString("/gs1 gs 1 0 0 rg 0 0 100 100 re f")
+	page := pw.AddPage(content, 0)
+	page.Group = &TransparencyGroup{ColorSpace: "/DeviceRGB"}
+	page.ExtGStates = map[Name]*ExtGState{
+		"gs1": {SoftMask: lum.ObjectNumber},
+		"gs2": {SoftMask: alpha.ObjectNumber},
+	}
+	if err := pw.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"/Type /Mask",
+		"/S /Luminosity",
+		"/S /Alpha",
+		fmt.Sprintf("/G %s", mask.Objnum.Ref()),
+		"/BC [0]",
+		"/FunctionType 4",
+		fmt.Sprintf("/SMask %s", lum.ObjectNumber.Ref()),
+		fmt.Sprintf("/SMask %s", alpha.ObjectNumber.Ref()),
+		"/CS /DeviceGray",
+		"/CS /DeviceRGB",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q", want)
+		}
+	}
+	if n := strings.Count(out, "/S /Transparency"); n != 2 {
+		t.Errorf("%d transparency groups, want 2", n)
+	}
+}
+
+func TestSoftMaskErrors(t *testing.T) {
+	pw, _ := newTestPDF()
+	noGroup := pw.NewFormXObject([4]float64{0, 0, 10, 10})
+	noCS := pw.NewFormXObject([4]float64{0, 0, 10, 10})
+	noCS.Group = &TransparencyGroup{}
+	gray := pw.NewFormXObject([4]float64{0, 0, 10, 10})
+	gray.Group = &TransparencyGroup{ColorSpace: "/DeviceGray"}
+	for _, sm := range []SoftMask{
+		{Subtype: "Opacity", Group: gray},
+		{Subtype: "Luminosity"},
+		{Subtype: "Luminosity", Group: noGroup},
+		{Subtype: "Luminosity", Group: noCS},
+		{Subtype: "Luminosity", Group: gray, BackdropColor: []float64{0, 0, 0}},
+		{Subtype: "Alpha", Group: noCS, BackdropColor: []float64{0}},
+		{Subtype: "Luminosity", Group: gray, TransferFunction: &Function{FunctionType: 2, C0: []float64{0, 0}, C1: []float64{1, 1}}},
+	} {
+		if _, err := pw.WriteSoftMask(sm); err == nil {
+			t.Errorf("expected an error for %+v", sm)
+		}
+	}
+
+	if _, err := writePDFATestPDF(t, &PDFA{Level: PDFA1b, ICCProfile: testICCProfile("RGB ")}, func(pw *PDF) {
+		pw.AddPage(pw.NewObject(), 0).Group = &TransparencyGroup{ColorSpace: "/DeviceRGB"}
+	}); err == nil {
+		t.Error("expected an error for a page group in PDF/A-1")
+	}
+}
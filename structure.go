@@ -0,0 +1,279 @@
+package pdf
+
+import (
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+)
+
+// standardStructureTypes are the structure types defined in ISO 32000-1,
+// section 14.8.4. Other roles must be mapped with StructTree.RoleMap.
+var standardStructureTypes = map[Name]bool{
+	"Document": true, "Part": true, "Art": true, "Sect": true, "Div": true,
+	"BlockQuote": true, "Caption": true, "TOC": true, "TOCI": true,
+	"Index": true, "NonStruct": true, "Private": true,
+	"P": true, "H": true, "H1": true, "H2": true, "H3": true, "H4": true,
+	"H5": true, "H6": true, "L": true, "LI": true, "Lbl": true, "LBody": true,
+	"Table": true, "TR": true, "TH": true, "TD": true, "THead": true,
+	"TBody": true, "TFoot": true,
+	"Span": true, "Quote": true, "Note": true, "Reference": true,
+	"BibEntry": true, "Code": true, "Link": true, "Annot": true,
+	"Ruby": true, "RB": true, "RT": true, "RP": true,
+	"Warichu": true, "WT": true, "WP": true,
+	"Figure": true, "Formula": true, "Form": true,
+}
+
+// StructTree is the logical structure of a tagged PDF. Get it with
+// PDF.StructTree, which also marks the document as tagged. The document
+// language for the catalog is taken from Metadata.Language.
+type StructTree struct {
+	// RoleMap maps custom roles to standard structure types.
+	RoleMap map[Name]Name
+	pw      *PDF
+	objnum  Objectnumber
+	kids    []*StructElem
+	// pageKeys are the /StructParents keys of the pages with marked
+	// content, pageMCIDs the structure elements for each MCID of a page.
+	pageKeys  map[*Page]int
+	pageMCIDs map[*Page][]*StructElem
+	// objectParents are the parent tree entries of annotations and
+	// XObjects (/StructParent).
+	objectParents map[int]*StructElem
+	nextKey       int
+}
+
+// StructElem is a structure element such as a paragraph, a heading or a
+// figure. Its content is marked content on pages (MarkContent) and
+// referenced objects (AddAnnotation, AddImage, AddObject).
+type StructElem struct {
+	// Role is the structure type (/S), either a standard type or a custom
+	// role in the role map.
+	Role Name
+	// Title is the title of the element (/T).
+	Title string
+	// Lang is the language of the element if it differs from the document
+	// language.
+	Lang string
+	// Alt is the alternate description, required for figures in PDF/UA.
+	Alt string
+	// ActualText is the exact replacement text of the content.
+	ActualText string
+	// Dict holds additional entries such as /A attributes.
+	Dict   Dict
+	tree   *StructTree
+	objnum Objectnumber
+	page   *Page
+	kids   []structKid
+}
+
+// structKid is a kid of a structure element: another element, a marked
+// content sequence or an object reference.
+type structKid struct {
+	elem *StructElem
+	page *Page
+	mcid int
+	obj  Objectnumber
+}
+
+// StructTree returns the structure tree of the document, creating it on the
+// first call. A document with a structure tree is a tagged PDF.
+func (pw *PDF) StructTree() *StructTree {
+	if pw.structTree == nil {
+		pw.structTree = &StructTree{
+			RoleMap:       make(map[Name]Name),
+			pw:            pw,
+			objnum:        pw.NextObject(),
+			pageKeys:      make(map[*Page]int),
+			pageMCIDs:     make(map[*Page][]*StructElem),
+			objectParents: make(map[int]*StructElem),
+		}
+	}
+	return pw.structTree
+}
+
+// Add appends a new top-level structure element. PDF/UA documents usually
+// have a single Document element here.
+func (st *StructTree) Add(role Name) *StructElem {
+	se := st.newElem(role)
+	st.kids = append(st.kids, se)
+	return se
+}
+
+func (st *StructTree) newElem(role Name) *StructElem {
+	return &StructElem{
+		Role:   role,
+		tree:   st,
+		objnum: st.pw.NextObject(),
+	}
+}
+
+// Add appends a new child element.
+func (se *StructElem) Add(role Name) *StructElem {
+	kid := se.tree.newElem(role)
+	se.kids = append(se.kids, structKid{elem: kid})
+	return kid
+}
+
+// MarkContent allocates the next marked content identifier on the page and
+// adds the marked content to the element. The content stream of the page
+// must enclose the content with
+//
+//	/<Role> <</MCID n>> BDC ... EMC
+//
+// where n is the returned value.
+func (se *StructElem) MarkContent(page *Page) int {
+	st := se.tree
+	if _, ok := st.pageKeys[page]; !ok {
+		st.pageKeys[page] = st.nextKey
+		st.nextKey++
+	}
+	mcid := len(st.pageMCIDs[page])
+	st.pageMCIDs[page] = append(st.pageMCIDs[page], se)
+	se.kids = append(se.kids, structKid{page: page, mcid: mcid})
+	if se.page == nil {
+		se.page = page
+	}
+	return mcid
+}
+
+// AddObject adds an object reference to the element and returns the key
+// that the object needs as its /StructParent entry.
+func (se *StructElem) AddObject(page *Page, obj Objectnumber) int {
+	st := se.tree
+	key := st.nextKey
+	st.nextKey++
+	st.objectParents[key] = se
+	se.kids = append(se.kids, structKid{page: page, obj: obj, mcid: -1})
+	if se.page == nil {
+		se.page = page
+	}
+	return key
+}
+
+// AddAnnotation adds the annotation to the page and references it from the
+// element, for example a link annotation from a Link element.
+func (se *StructElem) AddAnnotation(page *Page, annot Annotation) {
+	if annot.Objectnumber == 0 {
+		annot.Objectnumber = se.tree.pw.NextObject()
+	}
+	d := maps.Clone(annot.Dictionary)
+	if d == nil {
+		d = Dict{}
+	}
+	d["StructParent"] = strconv.Itoa(se.AddObject(page, annot.Objectnumber))
+	annot.Dictionary = d
+	page.Annotations = append(page.Annotations, annot)
+}
+
+// AddImage references the image XObject from the element and sets its
+// /StructParent. The image is painted without a marked content sequence.
+func (se *StructElem) AddImage(page *Page, img *Imagefile) {
+	img.SetStructParent(se.AddObject(page, img.ImageObject().ObjectNumber))
+}
+
+// kidsArray returns the /K entry of the element.
+func (se *StructElem) kidsArray() string {
+	k := make([]string, 0, len(se.kids))
+	for _, kid := range se.kids {
+		switch {
+		case kid.elem != nil:
+			k = append(k, kid.elem.objnum.Ref())
+		case kid.obj != 0:
+			k = append(k, fmt.Sprintf("<< /Type /OBJR /Obj %s /Pg %s >>", kid.obj.Ref(), kid.page.Objnum.Ref()))
+		case kid.page == se.page:
+			k = append(k, strconv.Itoa(kid.mcid))
+		default:
+			k = append(k, fmt.Sprintf("<< /Type /MCR /Pg %s /MCID %d >>", kid.page.Objnum.Ref(), kid.mcid))
+		}
+	}
+	return "[" + strings.Join(k, " ") + "]"
+}
+
+// write saves the element and its descendants.
+func (se *StructElem) write(parent Objectnumber) error {
+	if !standardStructureTypes[se.Role] {
+		if _, ok := se.tree.RoleMap[se.Role]; !ok {
+			return fmt.Errorf("pdf: structure type %s is neither standard nor in the role map", se.Role)
+		}
+	}
+	d := Dict{
+		"Type": "/StructElem",
+		"S":    se.Role.String(),
+		"P":    parent.Ref(),
+		"K":    se.kidsArray(),
+	}
+	if se.page != nil {
+		d["Pg"] = se.page.Objnum.Ref()
+	}
+	if se.Title != "" {
+		d["T"] = stringToPDF(se.Title)
+	}
+	if se.Lang != "" {
+		d["Lang"] = stringToPDF(se.Lang)
+	}
+	if se.Alt != "" {
+		d["Alt"] = stringToPDF(se.Alt)
+	}
+	if se.ActualText != "" {
+		d["ActualText"] = stringToPDF(se.ActualText)
+	}
+	maps.Copy(d, se.Dict)
+	obj := se.tree.pw.NewObjectWithNumber(se.objnum)
+	obj.Dict(d)
+	if err := obj.Save(); err != nil {
+		return err
+	}
+	for _, kid := range se.kids {
+		if kid.elem != nil {
+			if err := kid.elem.write(se.objnum); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// write saves the structure tree root, all elements and the parent tree.
+func (st *StructTree) write() error {
+	kids := make([]string, len(st.kids))
+	for i, se := range st.kids {
+		if err := se.write(st.objnum); err != nil {
+			return err
+		}
+		kids[i] = se.objnum.Ref()
+	}
+
+	parents := make(map[int]any, st.nextKey)
+	for page, key := range st.pageKeys {
+		elems := make([]string, len(st.pageMCIDs[page]))
+		for i, se := range st.pageMCIDs[page] {
+			elems[i] = se.objnum.Ref()
+		}
+		parents[key] = "[" + strings.Join(elems, " ") + "]"
+	}
+	for key, se := range st.objectParents {
+		parents[key] = se.objnum
+	}
+	parentTree, err := st.pw.WriteNumberTree(parents)
+	if err != nil {
+		return err
+	}
+
+	d := Dict{
+		"Type":              "/StructTreeRoot",
+		"K":                 "[" + strings.Join(kids, " ") + "]",
+		"ParentTree":        parentTree.Ref(),
+		"ParentTreeNextKey": strconv.Itoa(st.nextKey),
+	}
+	if len(st.RoleMap) > 0 {
+		rm := Dict{}
+		for k, v := range st.RoleMap {
+			rm[k] = v.String()
+		}
+		d["RoleMap"] = rm
+	}
+	root := st.pw.NewObjectWithNumber(st.objnum)
+	root.Dict(d)
+	return root.Save()
+}
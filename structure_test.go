@@ -0,0 +1,81 @@
+package pdf
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestStructTree(t *testing.T) {
+	pw, buf := newTestPDF()
+	pw.Metadata = &Metadata{Title: "Tagged", Language: "en"}
+	st := pw.StructTree()
+	st.RoleMap["Chapter"] = "Sect"
+
+	content1 := pw.NewObject()
+	page1 := pw.AddPage(content1, 0)
+	content2 := pw.NewObject()
+	page2 := pw.AddPage(content2, 0)
+
+	doc := st.Add("Document")
+	chapter := doc.Add("Chapter")
+	h1 := chapter.Add("H1")
+	h1.Lang = "de"
+	p := chapter.Add("P")
+	p.ActualText = "Hello world"
+
+	mcid := h1.MarkContent(page1)
+	fmt.Fprintf(content1.Data, "/H1 <</MCID %d>> BDC BT (Title) Tj ET EMC\n", mcid)
+	mcid = p.MarkContent(page1)
+	fmt.Fprintf(content1.Data, "/P <</MCID %d>> BDC BT (Hello) Tj ET EMC\n", mcid)
+	// the paragraph continues on the second page
+	if mcid = p.MarkContent(page2); mcid != 0 {
+		t.Errorf("MCIDs are per page, got %d", mcid)
+	}
+
+	link := p.Add("Link")
+	link.AddAnnotation(page2, Annotation{Subtype: "Link", Rect: [4]float64{0, 0, 10, 10}, Action: "<< /S /URI /URI (https://example.com) >>"})
+	figure := doc.Add("Figure")
+	figure.Alt = "A red square"
+	key := figure.AddObject(page2, 99)
+
+	if err := pw.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"/StructTreeRoot " + st.objnum.Ref(),
+		"/MarkInfo <<\n   /Marked true",
+		"/Lang (en)",
+		"/RoleMap <<\n   /Chapter /Sect",
+		"/ParentTreeNextKey 4",
+		"/Nums [0 [" + h1.objnum.Ref() + " " + p.objnum.Ref() + "] 1 [" + p.objnum.Ref() + "] 2 " + link.objnum.Ref() + " 3 " + figure.objnum.Ref() + "]",
+		"/S /Chapter",
+		"/Lang (de)",
+		"/ActualText (Hello world)",
+		"/Alt (A red square)",
+		"/StructParents 0",
+		"/StructParents 1",
+		"/StructParent 2",
+		"/Tabs /S",
+		"/K [1 << /Type /MCR /Pg " + page2.Objnum.Ref() + " /MCID 0 >> " + link.objnum.Ref() + "]",
+		"<< /Type /OBJR /Obj 99 0 R /Pg " + page2.Objnum.Ref() + " >>",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q", want)
+		}
+	}
+	if key != 3 {
+		t.Errorf("got struct parent key %d, want 3", key)
+	}
+}
+
+func TestStructTreeUnknownRole(t *testing.T) {
+	pw, _ := newTestPDF()
+	pw.AddPage(pw.NewObject(), 0)
+	pw.StructTree().Add("Document").Add("Chapter")
+	err := pw.Finish()
+	if err == nil || !strings.Contains(err.Error(), "/Chapter") {
+		t.Errorf("expected an error for the unmapped role, got %v", err)
+	}
+}
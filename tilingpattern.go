@@ -0,0 +1,73 @@
+package pdf
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// TilingPattern describes a PDF Pattern Dictionary of type 1 (Tiling
+// Pattern, Section 8.7.3.2). The pattern cell is drawn by Content and
+// repeated at XStep/YStep intervals.
+type TilingPattern struct {
+	// PaintType 1 is a coloured pattern whose content sets its own colours,
+	// 2 an uncoloured pattern painted in the colour given with scn, for
+	// example /<colour space> cs 0 0 1 /<pattern> scn with the colour space
+	// [/Pattern /DeviceRGB].
+	PaintType int
+	// TilingType 1 is constant spacing, 2 no distortion and 3 constant
+	// spacing with faster tiling.
+	TilingType int
+	BBox       [4]float64 // the pattern cell [llx lly urx ury]
+	XStep      float64
+	YStep      float64
+	// Matrix maps pattern space to the default coordinate space of the
+	// page. The zero matrix is the identity.
+	Matrix    [6]float64 // PDF order: a b c d e f
+	Content   string     // the content stream of the pattern cell
+	Resources Resources
+}
+
+// WriteTilingPattern writes the pattern with its content stream and returns
+// the Pattern object so callers can reference it from a Page's
+// /Resources/Pattern entry or from the resources of another pattern.
+func (pw *PDF) WriteTilingPattern(p TilingPattern) (*Object, error) {
+	if p.PaintType != 1 && p.PaintType != 2 {
+		return nil, fmt.Errorf("pdf: tiling pattern PaintType %d is not 1 or 2", p.PaintType)
+	}
+	if p.TilingType < 1 || p.TilingType > 3 {
+		return nil, fmt.Errorf("pdf: tiling pattern TilingType %d is not between 1 and 3", p.TilingType)
+	}
+	if p.XStep == 0 || p.YStep == 0 {
+		return nil, fmt.Errorf("pdf: tiling pattern XStep and YStep must not be 0")
+	}
+	if p.BBox[0] == p.BBox[2] || p.BBox[1] == p.BBox[3] {
+		return nil, fmt.Errorf("pdf: tiling pattern BBox is empty")
+	}
+	res, err := pw.resourceDict(p.Resources)
+	if err != nil {
+		return nil, err
+	}
+	pw.useResources(p.Resources)
+
+	patternObj := pw.NewObject()
+	patternObj.Dictionary = Dict{
+		"Type":        "/Pattern",
+		"PatternType": "1",
+		"PaintType":   strconv.Itoa(p.PaintType),
+		"TilingType":  strconv.Itoa(p.TilingType),
+		"BBox":        numberArray(p.BBox[:]),
+		"XStep":       fmtPDFFloat(p.XStep),
+		"YStep":       fmtPDFFloat(p.YStep),
+		"Resources":   res,
+	}
+	if p.Matrix != [6]float64{} {
+		patternObj.Dictionary["Matrix"] = numberArray(p.Matrix[:])
+	}
+	patternObj.Data.WriteString(p.Content)
+	patternObj.ForceStream = true
+	patternObj.SetCompression(9)
+	if err := patternObj.Save(); err != nil {
+		return nil, err
+	}
+	return patternObj, nil
+}
@@ -0,0 +1,108 @@
+package pdf
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestTilingPattern(t *testing.T) {
+	pw, buf := newTestPDF()
+	img, err := pw.LoadImageFile(writeTempPNG(t, t.TempDir(), 4, 4, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	alpha := 0.5
+	dot, err := pw.WriteShadingPattern(ShadingPattern{
+		Shading: Shading{
+			ShadingType: 2,
+			Coords:      []float64{0, 0, 10, 0},
+			Function:    Function{FunctionType: 2, C0: []float64{1, 0, 0}, C1: []float64{0, 0, 1}, N: 1},
+		},
+		Matrix: [6]float64{1, 0, 0, 1, 0, 0},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tile, err := pw.WriteTilingPattern(TilingPattern{
+		PaintType:  1,
+		TilingType: 1,
+		BBox:       [4]float64{0, 0, 10, 10},
+		XStep:      10,
+		YStep:      10,
+		Content:    "/gs1 gs /Pattern cs /p1 scn 0 0 5 5 re f q 5 0 0 5 5 5 cm " + img.InternalName() + " Do Q",
+		Resources: Resources{
+			Images:     []*Imagefile{img},
+			Patterns:   map[Name]*Object{"p1": dot},
+			ExtGStates: map[Name]*ExtGState{"gs1": {FillAlpha: &alpha}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("WriteTilingPattern: %v", err)
+	}
+	hatch, err := pw.WriteTilingPattern(TilingPattern{
+		PaintType:  2,
+		TilingType: 3,
+		BBox:       [4]float64{0, 0, 4, 4},
+		XStep:      4,
+		YStep:      4,
+		Matrix:     [6]float64{0.7071, 0.7071, -0.7071, 0.7071, 0, 0},
+		Content:    "0 0 m 4 0 l S",
+	})
+	if err != nil {
+		t.Fatalf("WriteTilingPattern: %v", err)
+	}
+	content := pw.NewObject()
+	content.Data.WriteString("/Pattern cs /tile scn 0 0 100 100 re f /cs1 cs 0 0 1 /hatch scn 0 100 100 100 re f")
+	page := pw.AddPage(content, 0)
+	page.Patterns = map[Name]*Object{"tile": tile, "hatch": hatch}
+	page.ColorSpaces = map[Name]Name{"cs1": "[/Pattern /DeviceRGB]"}
+	if err := pw.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"/PatternType 1",
+		"/PaintType 1",
+		"/PaintType 2",
+		"/TilingType 3",
+		"/BBox [0 0 10 10]",
+		"/XStep 10",
+		"/YStep 4",
+		"/Matrix [0.7071 0.7071 -0.7071 0.7071 0 0]",
+		fmt.Sprintf("/p1 %s", dot.ObjectNumber.Ref()),
+		fmt.Sprintf("%s %s", img.InternalName(), img.ImageObject().ObjectNumber.Ref()),
+		fmt.Sprintf("/tile %s", tile.ObjectNumber.Ref()),
+		fmt.Sprintf("/hatch %s", hatch.ObjectNumber.Ref()),
+		"/cs1 [/Pattern /DeviceRGB]",
+		"/Subtype /Image",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q", want)
+		}
+	}
+	if _, data := streamData(t, buf.Bytes(), int(hatch.ObjectNumber)); string(data) != "0 0 m 4 0 l S" {
+		t.Errorf("pattern content = %q", data)
+	}
+}
+
+func TestTilingPatternErrors(t *testing.T) {
+	valid := TilingPattern{PaintType: 1, TilingType: 1, BBox: [4]float64{0, 0, 10, 10}, XStep: 10, YStep: 10}
+	for _, modify := range []func(p *TilingPattern){
+		func(p *TilingPattern) { p.PaintType = 0 },
+		func(p *TilingPattern) { p.TilingType = 4 },
+		func(p *TilingPattern) { p.XStep = 0 },
+		func(p *TilingPattern) { p.BBox = [4]float64{0, 0, 0, 10} },
+		func(p *TilingPattern) {
+			neg := -1.0
+			p.Resources.ExtGStates = map[Name]*ExtGState{"gs1": {LineWidth: &neg}}
+		},
+	} {
+		pw, _ := newTestPDF()
+		p := valid
+		modify(&p)
+		if _, err := pw.WriteTilingPattern(p); err == nil {
+			t.Errorf("expected an error for %+v", p)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"compress/zlib"
 	"crypto/md5"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -69,13 +70,74 @@ type Pages struct {
 }
 
 // An Annotation is a PDF element that is additional to the text, such as a
-// hyperlink or a note.
+// hyperlink or a note. Use the constructors such as NewTextAnnotation or
+// NewHighlightAnnotation for markup annotations, the writer generates their
+// appearance streams.
 type Annotation struct {
-	Dictionary   Dict
-	Subtype      Name
-	Action       string
-	Rect         [4]float64   // x1, y1, x2, y2
-	Objectnumber Objectnumber // pre-reserved object number (0 = auto-assign)
+	Dictionary Dict
+	Subtype    Name
+	// Action is an action dictionary such as << /S /URI /URI (...) >>,
+	// which is written as is.
+	//
+	// Deprecated: use OnActivate.
+	Action string
+	// OnActivate is performed when the user activates the annotation, for
+	// example by clicking a link. It can not be used together with Action.
+	OnActivate *Action
+	// AdditionalActions are performed on events such as the mouse entering
+	// the annotation (E) or the page with the annotation being opened (PO).
+	AdditionalActions AdditionalActions
+	Rect              [4]float64   // x1, y1, x2, y2
+	Objectnumber      Objectnumber // pre-reserved object number (0 = auto-assign)
+	// Flags are the annotation flags such as AnnotationPrint.
+	Flags int
+	// Contents is the text of the annotation or a description for
+	// annotations without text.
+	Contents string
+	// Author is the title of the pop-up window of a markup annotation,
+	// usually the name of the author.
+	Author string
+	// Modified is the time of the last modification.
+	Modified time.Time
+	// Color is the colour of the annotation: the icon of a note, the
+	// background of a free text annotation or the lines of the other
+	// markup annotations. It has 1 (gray), 3 (RGB) or 4 (CMYK) components.
+	Color []float64
+	// InteriorColor fills squares, circles and polygons.
+	InteriorColor []float64
+	// Opacity is the constant opacity of a markup annotation between 0 and
+	// 1. Nil means opaque.
+	Opacity *float64
+	// BorderWidth is the line width of free text, square, circle, line,
+	// polygon, polyline and ink annotations.
+	BorderWidth float64
+	// InReplyTo is the annotation this annotation is a reply to. The other
+	// annotation needs a pre-reserved Objectnumber.
+	InReplyTo Objectnumber
+	// Popup is the pop-up window showing the text of a markup annotation.
+	// It is written together with the annotation.
+	Popup *Annotation
+	// Open shows the pop-up window of a note or a pop-up annotation
+	// initially open.
+	Open bool
+	// Icon is the name of the icon of a note (Comment, Key, Note, Help,
+	// NewParagraph, Paragraph, Insert) or a stamp (Approved, Draft, ...).
+	Icon Name
+	// QuadPoints are the quadrilaterals of a text markup annotation, eight
+	// numbers each: upper left, upper right, lower left and lower right
+	// corner.
+	QuadPoints []float64
+	// Vertices are the end points of a line (x1 y1 x2 y2) or the vertices of
+	// a polygon or polyline.
+	Vertices []float64
+	// InkList are the paths of an ink annotation, each a list of x y
+	// coordinates.
+	InkList [][]float64
+	// Face is the font of free text annotations and of the stamp text.
+	Face     *Face
+	FontSize float64
+	// OptionalContent shows the annotation only if the layer is visible.
+	OptionalContent OptionalContent
 }
 
 // Separation represents a spot color
@@ -97,25 +159,70 @@ type Page struct {
 	Annotations   []Annotation
 	Faces         []*Face
 	Images        []*Imagefile
+	// Forms are the Form XObjects painted on the page with
+	// <InternalName> Do. They share /Resources/XObject with the images.
+	Forms []*FormXObject
 	// Patterns maps a per-page-unique resource name (without the leading
 	// slash) to the indirect Pattern object returned by
-	// PDF.WriteShadingPattern. Entries land in /Resources/Pattern; the
-	// renderer (e.g. svgreader) refers to them as "/<name> scn" inside the
-	// content stream.
+	// PDF.WriteShadingPattern or PDF.WriteTilingPattern. Entries land in
+	// /Resources/Pattern; the renderer (e.g. svgreader) refers to them as
+	// "/<name> scn" inside the content stream.
 	Patterns map[Name]*Object
-	Objnum   Objectnumber // The "/Page" object
-	Width    float64
-	Height   float64
-	OffsetX  float64
-	OffsetY  float64
+	// ColorSpaces maps a per-page-unique resource name (without the leading
+	// slash) to a colour space array such as [/Pattern /DeviceRGB] for
+	// uncoloured tiling patterns, or a reference to one.
+	ColorSpaces map[Name]Name
+	// Shadings maps a per-page-unique resource name (without the leading
+	// slash) to the shading object returned by PDF.WriteShading. The content
+	// stream paints it with /<name> sh.
+	Shadings map[Name]*Object
+	// ExtGStates maps a per-page-unique resource name (without the leading
+	// slash) to a graphics state which the content stream sets with
+	// /<name> gs.
+	ExtGStates map[Name]*ExtGState
+	// Properties maps a per-page-unique resource name (without the leading
+	// slash) to an optional content group or membership dictionary. The
+	// content stream marks optional content with /OC /<name> BDC ... EMC.
+	Properties map[Name]OptionalContent
+	// Group makes the page a transparency group, mostly to set the
+	// blending colour space of its transparent content.
+	Group *TransparencyGroup
+	// AdditionalActions are performed when the page is opened (O) or closed
+	// (C).
+	AdditionalActions AdditionalActions
+	Objnum            Objectnumber // The "/Page" object
+	Width             float64
+	Height            float64
+	OffsetX           float64
+	OffsetY           float64
 }
 
+// OutlineStyle is a set of flags for the text style of an outline entry.
+type OutlineStyle int
+
+const (
+	// OutlineItalic shows the outline entry in italic.
+	OutlineItalic OutlineStyle = 1 << iota
+	// OutlineBold shows the outline entry in bold.
+	OutlineBold
+)
+
 // Outline represents PDF bookmarks. To create outlines, you need to assign
-// previously created Dest items to the outline. When Open is true, the PDF
-// viewer shows the child outlines.
+// previously created Dest items, a Destination or an Action to the outline.
+// When Open is true, the PDF viewer shows the child outlines.
 type Outline struct {
-	Title        string
-	Dest         string
+	Title string
+	Dest  string
+	// Destination is used instead of Dest if set. Destinations in other
+	// documents are written as GoToR or GoToE actions.
+	Destination *Destination
+	// Action is performed instead of going to a destination.
+	Action *Action
+	// Color is the RGB color of the entry, each component between 0 and 1.
+	Color []float64
+	Style OutlineStyle
+	// StructElem is the structure element the outline entry refers to.
+	StructElem   *StructElem
 	Children     []*Outline
 	objectNumber Objectnumber
 	Open         bool
@@ -129,18 +236,88 @@ type PDF struct {
 	NameDestinations map[String]*NameDest
 	names            Dict
 	objectlocations  map[Objectnumber]int64
-	pages            *Pages
+	// compressedLocations holds the objects that were packed into an
+	// object stream. They have no byte offset of their own.
+	compressedLocations map[Objectnumber]compressedLocation
+	objStm              *objectStream
+	pages               *Pages
 
 	// having a zlib writer here and using reset removes lots
 	// of allocations that would happen with
 	// a new zlib writer for each stream
-	zlibWriter        *zlib.Writer
-	Colorspaces       []*Separation
-	Outlines          []*Outline
+	zlibWriter  *zlib.Writer
+	Colorspaces []*Separation
+	Outlines    []*Outline
+	// OpenAction is performed when the document is opened, for example a
+	// GoTo action to show a certain page.
+	OpenAction *Action
+	// PageLabels are the ranges of page labels shown by viewers instead of
+	// the page numbers.
+	PageLabels []PageLabel
+	// OCGOrder is the list of layers shown in the viewer. If it is nil, all
+	// optional content groups are listed in the order of creation.
+	OCGOrder []*OCGOrderEntry
+	// OCGRadioButtons are sets of optional content groups of which at most
+	// one is visible at a time.
+	OCGRadioButtons   [][]*OptionalContentGroup
 	DefaultOffsetX    float64
 	DefaultOffsetY    float64
 	DefaultPageWidth  float64
 	DefaultPageHeight float64
+	// ObjectStreams makes Finish write a cross-reference stream instead of
+	// a classic xref table and trailer. All non-stream objects saved while
+	// ObjectStreams is true are packed into compressed object streams. This
+	// requires PDF 1.5 or later and usually reduces the file size
+	// considerably for documents with many small dictionaries.
+	ObjectStreams bool
+	// PageTreeFanout is the maximum number of kids of a /Pages node. Larger
+	// documents get a balanced tree of intermediate nodes. The default is
+	// 32.
+	PageTreeFanout int
+	// security is set by SetEncryption. fileID is the first element of the
+	// trailer /ID, which must be known in advance for encrypted documents.
+	security *securityHandler
+	fileID   []byte
+	// signature is set by AddSignature. If the output cannot be patched,
+	// outfile is replaced by a buffer and signatureOut holds the real
+	// output until Finish is done.
+	signature    *Signature
+	signatureOut io.Writer
+	// PDFA requests a PDF/A file. See the PDFA type.
+	PDFA *PDFA
+	// Metadata is the typed document metadata. See the Metadata type.
+	Metadata *Metadata
+	// attachments are the files added with AttachFile.
+	attachments []*Attachment
+	// formFields are the root fields of the interactive form and formFaces
+	// the faces used by the fields, in the order of first use.
+	formFields []*FormField
+	formFaces  []*Face
+	// resourceFaces and resourceImages are used by patterns and other
+	// content streams outside of pages.
+	resourceFaces  []*Face
+	resourceImages []*Imagefile
+	// formXObjects are the Form XObjects in the order of creation.
+	formXObjects []*FormXObject
+	// colorSpaces are the device-independent colour spaces. iccProfiles
+	// maps the hash of an ICC profile and its alternate colour space to the
+	// profile stream and iccColorSpaces the profile stream to its colour
+	// space, so identical profiles are embedded only once.
+	colorSpaces    []*ColorSpace
+	iccProfiles    map[string]Objectnumber
+	iccColorSpaces map[Objectnumber]*ColorSpace
+	// structTree is the logical structure of a tagged PDF.
+	structTree *StructTree
+	// ocgs and ocmds are the optional content groups and membership
+	// dictionaries in the order of creation.
+	ocgs  []*OptionalContentGroup
+	ocmds []*OptionalContentMembership
+	// extGStates maps the serialized graphics states to their objects, so
+	// identical states are written only once.
+	extGStates map[string]Objectnumber
+	// conformanceErrors collects the PDF/A violations found while saving
+	// objects.
+	conformanceErrors []error
 	version           Version
 	NoPages           int // set when PDF is finished
 	lastEOL           int64
@@ -170,12 +347,13 @@ func NewPDFWriter(file io.Writer) *PDF {
 	// process (e.g. glu's multi-pass aux-convergence loop, or an in-memory
 	// placeholder image generated while the main document is assembled).
 	pw := PDF{
-		version:          Version17,
-		NameDestinations: make(map[String]*NameDest),
-		objectlocations:  make(map[Objectnumber]int64),
-		zlibWriter:       zlib.NewWriter(io.Discard),
-		names:            make(Dict),
-		InfoDict:         make(Dict),
+		version:             Version17,
+		NameDestinations:    make(map[String]*NameDest),
+		objectlocations:     make(map[Objectnumber]int64),
+		compressedLocations: make(map[Objectnumber]compressedLocation),
+		zlibWriter:          zlib.NewWriter(io.Discard),
+		names:               make(Dict),
+		InfoDict:            make(Dict),
 	}
 	pw.outfile = file
 	pw.nextobject = 1
@@ -297,18 +475,6 @@ func (pw *PDF) writeInfoDict() (*Object, error) {
 	if pw.version.hasInfoDict() {
 		info := pw.NewObject()
 		info.Dictionary = pw.InfoDict
-		if info.Dictionary == nil {
-			info.Dictionary = make(Dict)
-		}
-		if info.Dictionary["Producer"] == nil {
-			info.Dictionary["Producer"] = stringToPDF("baseline-pdf/boxes and glue")
-		}
-		if info.Dictionary["Creator"] == nil {
-			info.Dictionary["Creator"] = stringToPDF("baseline-pdf")
-		}
-		if info.Dictionary["CreationDate"] == nil {
-			info.Dictionary["CreationDate"] = pdfDate(time.Now())
-		}
 		info.Save()
 		return info, nil
 	}
@@ -319,6 +485,12 @@ func (pw *PDF) writeDocumentCatalogAndPages() (Objectnumber, error) {
 	var err error
 	usedFaces := make(map[*Face]bool)
 	usedImages := make(map[*Imagefile]bool)
+	if err = pw.writeFormXObjects(); err != nil {
+		return 0, err
+	}
+	for _, img := range pw.resourceImages {
+		usedImages[img] = true
+	}
 	// Write all page streams:
 	for _, page := range pw.pages.Pages {
 		for _, img := range page.Images {
@@ -354,118 +526,82 @@ func (pw *PDF) writeDocumentCatalogAndPages() (Objectnumber, error) {
 		return 0, fmt.Errorf("no pages in document")
 	}
 
+	pageObjects := make([]*Object, 0, len(pw.pages.Pages))
 	for _, page := range pw.pages.Pages {
 		obj := pw.NewObjectWithNumber(page.Objnum)
-		fnts := Dict{}
-		if len(page.Faces) > 0 {
-			for _, face := range page.Faces {
-				fnts[Name(face.InternalName())] = face.fontobject.ObjectNumber.Ref()
-			}
-		}
-
-		resHash := Dict{}
-		if len(page.Faces) > 0 {
-			for _, face := range page.Faces {
-				usedFaces[face] = true
-			}
-			resHash["Font"] = fnts
-		}
-		if len(pw.Colorspaces) > 0 {
-			colorspace := Dict{}
-
-			for _, cs := range pw.Colorspaces {
-				colorspace[Name(cs.ID)] = cs.Obj.String()
-			}
-			resHash["ColorSpace"] = colorspace
+		for _, face := range page.Faces {
+			usedFaces[face] = true
 		}
-		if len(page.Images) > 0 {
-			xo := Dict{}
-			for _, img := range page.Images {
-				xo[Name(img.InternalName())] = img.imageobject.ObjectNumber.Ref()
-			}
-			resHash["XObject"] = xo
-		}
-		// Shading patterns produced by WriteShadingPattern. Pattern names
-		// are passed through verbatim; the SVG renderer reuses the same
-		// names in the page content stream.
-		if len(page.Patterns) > 0 {
-			pat := Dict{}
-			for name, obj := range page.Patterns {
-				pat[name] = obj.ObjectNumber.Ref()
-			}
-			resHash["Pattern"] = pat
+		resHash, err := pw.resourceDict(Resources{
+			Faces:       page.Faces,
+			Images:      page.Images,
+			Forms:       page.Forms,
+			Patterns:    page.Patterns,
+			Shadings:    page.Shadings,
+			ExtGStates:  page.ExtGStates,
+			Properties:  page.Properties,
+			ColorSpaces: page.ColorSpaces,
+		})
+		if err != nil {
+			return 0, err
 		}
 		pageHash := Dict{
 			"Type":     "/Page",
 			"Contents": page.contentStream.ObjectNumber.Ref(),
-			"Parent":   pagesObj.ObjectNumber.Ref(),
-		}
-		// MediaBox must be [llx lly urx ury] = [OffsetX OffsetY OffsetX+Width OffsetY+Height]
-		if page.OffsetX != pw.DefaultOffsetX || page.OffsetY != pw.DefaultOffsetY ||
-			page.Width != pw.DefaultPageWidth || page.Height != pw.DefaultPageHeight {
-
-			urx := page.OffsetX + page.Width
-			ury := page.OffsetY + page.Height
-			pageHash["MediaBox"] = fmt.Sprintf("[%s %s %s %s]",
-				FloatToPoint(page.OffsetX),
-				FloatToPoint(page.OffsetY),
-				FloatToPoint(urx),
-				FloatToPoint(ury),
-			)
 		}
+		// MediaBox must be [llx lly urx ury] = [OffsetX OffsetY OffsetX+Width OffsetY+Height].
+		// Boxes shared by many pages are moved up the page tree.
+		pageHash["MediaBox"] = fmt.Sprintf("[%s %s %s %s]",
+			FloatToPoint(page.OffsetX),
+			FloatToPoint(page.OffsetY),
+			FloatToPoint(page.OffsetX+page.Width),
+			FloatToPoint(page.OffsetY+page.Height),
+		)
 		if len(resHash) > 0 {
 			pageHash["Resources"] = resHash
 		}
+		if page.Group != nil {
+			pageHash["Group"] = page.Group.dict()
+		}
 
-		annotationObjectNumbers := make([]string, len(page.Annotations))
-		for i, annot := range page.Annotations {
-			var annotObj *Object
-			if annot.Objectnumber != 0 {
-				annotObj = pw.NewObjectWithNumber(annot.Objectnumber)
-			} else {
-				annotObj = pw.NewObject()
-			}
-			annotDict := Dict{
-				"Type":    "/Annot",
-				"Subtype": annot.Subtype.String(),
-				"A":       annot.Action,
-				"Rect":    fmt.Sprintf("[%s %s %s %s]", FloatToPoint(annot.Rect[0]), FloatToPoint(annot.Rect[1]), FloatToPoint(annot.Rect[2]), FloatToPoint(annot.Rect[3])),
+		var annotationObjectNumbers []string
+		for i := range page.Annotations {
+			annot := &page.Annotations[i]
+			if annot.Face != nil {
+				usedFaces[annot.Face] = true
 			}
-			maps.Copy(annotDict, annot.Dictionary)
-
-			annotObj.Dict(annotDict)
-			if err := annotObj.Save(); err != nil {
+			refs, err := pw.writeAnnotation(annot)
+			if err != nil {
 				return 0, err
 			}
-			annotationObjectNumbers[i] = annotObj.ObjectNumber.Ref()
+			annotationObjectNumbers = append(annotationObjectNumbers, refs...)
 		}
 		if len(annotationObjectNumbers) > 0 {
 			pageHash["Annots"] = "[" + strings.Join(annotationObjectNumbers, " ") + "]"
+			if pw.structTree != nil {
+				// PDF/UA: tab order follows the structure
+				pageHash["Tabs"] = "/S"
+			}
+		}
+		if st := pw.structTree; st != nil {
+			if key, ok := st.pageKeys[page]; ok {
+				pageHash["StructParents"] = strconv.Itoa(key)
+			}
+		}
+		if len(page.AdditionalActions) > 0 {
+			if err = pw.checkAdditionalActions(page.AdditionalActions, pageTriggers); err != nil {
+				return 0, err
+			}
+			pageHash["AA"] = page.AdditionalActions
 		}
 		maps.Copy(pageHash, page.Dict)
 		obj.Dict(pageHash)
-		obj.Save()
-	}
-
-	// The pages object
-	kids := make([]string, len(pw.pages.Pages))
-	for i, v := range pw.pages.Pages {
-		kids[i] = v.Objnum.Ref()
+		pageObjects = append(pageObjects, obj)
 	}
 
+	// The pages tree
 	pw.pages.objnum = pagesObj.ObjectNumber
-	urx := pw.DefaultOffsetX + pw.DefaultPageWidth
-	ury := pw.DefaultOffsetY + pw.DefaultPageHeight
-	pagesObj.Dict(Dict{
-		"Type":  "/Pages",
-		"Kids":  "[ " + strings.Join(kids, " ") + " ]",
-		"Count": fmt.Sprint(len(pw.pages.Pages)),
-		"MediaBox": fmt.Sprintf("[%s %s %s %s]",
-			FloatToPoint(pw.DefaultOffsetX), FloatToPoint(pw.DefaultOffsetY),
-			FloatToPoint(urx), FloatToPoint(ury),
-		),
-	})
-	if err = pagesObj.Save(); err != nil {
+	if err = pw.writePageTree(pagesObj, pageObjects); err != nil {
 		return 0, err
 	}
 
@@ -500,47 +636,79 @@ func (pw *PDF) writeDocumentCatalogAndPages() (Objectnumber, error) {
 	if pw.Outlines != nil {
 		dictCatalog["/Outlines"] = outlinesOjbNum.Ref()
 	}
-
-	if len(pw.NameDestinations) != 0 {
-		type name struct {
-			name String
-			onum Objectnumber
+	if pw.OpenAction != nil {
+		if err = pw.checkAction(pw.OpenAction); err != nil {
+			return 0, err
 		}
-		destnames := make([]name, 0, len(pw.NameDestinations))
+		dictCatalog["OpenAction"] = pw.OpenAction
+	}
 
+	if len(pw.NameDestinations) != 0 {
 		sortedNames := make([]String, 0, len(pw.NameDestinations))
 		for destname := range pw.NameDestinations {
 			sortedNames = append(sortedNames, destname)
 		}
 		slices.Sort(sortedNames)
+		dests := make(NameTreeData, len(sortedNames))
 		for _, n := range sortedNames {
 			nd := pw.NameDestinations[n]
-			nd.objectnumber, err = pw.writeDestObj(nd.PageObjectnumber, nd.X, nd.Y)
+			d := nd.Destination
+			if d == nil {
+				d = &Destination{Page: nd.PageObjectnumber, Left: nd.X, Top: nd.Y}
+			}
+			nd.objectnumber, err = pw.writeDestObj(d)
 			if err != nil {
-				return 0, err
+				return 0, fmt.Errorf("%w (destination %s)", err, nd.Name)
 			}
-			destnames = append(destnames, name{name: nd.Name, onum: nd.objectnumber})
-		}
-
-		var limitsAry, namesAry Array
-		limitsAry = append(limitsAry, destnames[0].name)
-		limitsAry = append(limitsAry, destnames[len(destnames)-1].name)
-		for _, n := range destnames {
-			namesAry = append(namesAry, String(n.name))
-			namesAry = append(namesAry, n.onum.Ref())
-		}
-
-		destNameTree := Dict{
-			"Limits": Serialize(limitsAry),
-			"Names":  Serialize(namesAry),
+			dests[nd.Name] = nd.objectnumber
 		}
-
-		pw.names["Dests"] = destNameTree
+		pw.names["Dests"] = Dict{"Names": dests}
 	}
 
+	if af := pw.writeAttachments(); af != "" {
+		dictCatalog["AF"] = af
+	}
+	if pl, err := pw.writePageLabels(); err != nil {
+		return 0, err
+	} else if pl != 0 {
+		dictCatalog["PageLabels"] = pl.Ref()
+	}
+	if err = pw.writeNameTrees(); err != nil {
+		return 0, err
+	}
 	if len(pw.names) > 0 {
 		dictCatalog["Names"] = pw.names
 	}
+	if st := pw.structTree; st != nil {
+		if err = st.write(); err != nil {
+			return 0, err
+		}
+		dictCatalog["StructTreeRoot"] = st.objnum.Ref()
+		dictCatalog["MarkInfo"] = Dict{"Marked": "true"}
+	}
+	if oc, err := pw.writeOptionalContent(); err != nil {
+		return 0, err
+	} else if oc != nil {
+		dictCatalog["OCProperties"] = oc
+	}
+	if af := pw.acroForm(); af != nil {
+		dictCatalog["AcroForm"] = af
+	}
+	if pw.hasMetadataStream() {
+		md, err := pw.writeMetadata()
+		if err != nil {
+			return 0, err
+		}
+		dictCatalog["Metadata"] = md.ObjectNumber.Ref()
+	}
+	if pw.Metadata != nil && pw.Metadata.Language != "" {
+		dictCatalog["Lang"] = stringToPDF(pw.Metadata.Language)
+	}
+	if pw.PDFA != nil {
+		if dictCatalog["OutputIntents"], err = pw.writeOutputIntent(); err != nil {
+			return 0, err
+		}
+	}
 	maps.Copy(dictCatalog, pw.Catalog)
 	catalog.Dict(dictCatalog)
 	if err = catalog.Save(); err != nil {
@@ -548,6 +716,12 @@ func (pw *PDF) writeDocumentCatalogAndPages() (Objectnumber, error) {
 	}
 
 	// write out all font descriptors and files into the PDF
+	for _, face := range pw.formFaces {
+		usedFaces[face] = true
+	}
+	for _, face := range pw.resourceFaces {
+		usedFaces[face] = true
+	}
 	sortedFaces := make([]*Face, 0, len(usedFaces))
 	for k := range usedFaces {
 		sortedFaces = append(sortedFaces, k)
@@ -565,19 +739,6 @@ func (pw *PDF) writeDocumentCatalogAndPages() (Objectnumber, error) {
 	return catalog.ObjectNumber, nil
 }
 
-func (pw *PDF) writeDestObj(page Objectnumber, x, y float64) (Objectnumber, error) {
-	obj := pw.NewObject()
-	dest := fmt.Sprintf("[%s /XYZ %0.5g %0.5g null]", page.Ref(), x, y)
-	obj.Dict(Dict{
-		"D": dest,
-	})
-
-	if err := obj.Save(); err != nil {
-		return 0, err
-	}
-	return obj.ObjectNumber, nil
-}
-
 func (pw *PDF) writeOutline(parentObj *Object, outlines []*Outline) (first Objectnumber, last Objectnumber, c int, err error) {
 	for _, outline := range outlines {
 		outline.objectNumber = pw.NextObject()
@@ -590,7 +751,45 @@ func (pw *PDF) writeOutline(parentObj *Object, outlines []*Outline) (first Objec
 		outlineDict := Dict{}
 		outlineDict["Parent"] = parentObj.ObjectNumber.Ref()
 		outlineDict["Title"] = stringToPDF(outline.Title)
-		outlineDict["Dest"] = Serialize(outline.Dest)
+		if outline.Action != nil && (outline.Destination != nil || outline.Dest != "") {
+			err = fmt.Errorf("pdf: outline %q has both an action and a destination", outline.Title)
+			return
+		}
+		action := outline.Action
+		if d := outline.Destination; d != nil {
+			switch {
+			case d.Embedded != nil:
+				action = &Action{Type: ActionGoToE, Destination: d}
+			case d.File != "":
+				action = &Action{Type: ActionGoToR, Destination: d}
+			default:
+				if err = pw.checkDestination(d); err != nil {
+					return
+				}
+				outlineDict["Dest"] = d.explicit()
+			}
+		} else if outline.Dest != "" {
+			outlineDict["Dest"] = Serialize(outline.Dest)
+		}
+		if action != nil {
+			if err = pw.checkAction(action); err != nil {
+				return
+			}
+			outlineDict["A"] = action
+		}
+		if outline.Color != nil {
+			if len(outline.Color) != 3 || slices.ContainsFunc(outline.Color, func(f float64) bool { return f < 0 || f > 1 }) {
+				err = fmt.Errorf("pdf: outline %q color must be three RGB values between 0 and 1, got %v", outline.Title, outline.Color)
+				return
+			}
+			outlineDict["C"] = colorArray(outline.Color)
+		}
+		if outline.Style != 0 {
+			outlineDict["F"] = strconv.Itoa(int(outline.Style & (OutlineItalic | OutlineBold)))
+		}
+		if outline.StructElem != nil {
+			outlineDict["SE"] = outline.StructElem.objnum.Ref()
+		}
 
 		if i < len(outlines)-1 {
 			outlineDict["Next"] = outlines[i+1].objectNumber.Ref()
@@ -612,21 +811,34 @@ func (pw *PDF) writeOutline(parentObj *Object, outlines []*Outline) (first Objec
 			}
 			outlineDict["First"] = cldFirst.Ref()
 			outlineDict["Last"] = cldLast.Ref()
+			// count is the number of visible entries below an open outline.
+			// Closed outlines hide them and store the negative number.
 			if outline.Open {
-				outlineDict["Count"] = fmt.Sprintf("%d", count)
+				outlineDict["Count"] = strconv.Itoa(count)
+				c += count
 			} else {
-				outlineDict["Count"] = "-1"
+				outlineDict["Count"] = strconv.Itoa(-count)
 			}
-			c += count
 		}
 		outlineObj.Dictionary = outlineDict
-		outlineObj.Save()
+		if err = outlineObj.Save(); err != nil {
+			return
+		}
 	}
 	return
 }
 
 // Finish writes the trailer and xref section but does not close the file.
+//
+// For PDF/A documents Finish returns the conformance violations as an error
+// before the cross-reference section is written.
 func (pw *PDF) Finish() error {
+	if pw.PDFA != nil {
+		if err := pw.checkPDFASettings(); err != nil {
+			return err
+		}
+	}
+	pw.syncMetadata()
 	dc, err := pw.writeDocumentCatalogAndPages()
 	if err != nil {
 		return err
@@ -637,7 +849,62 @@ func (pw *PDF) Finish() error {
 		return err
 	}
 
-	// XRef section
+	trailer := Dict{
+		"Root": dc.Ref(),
+	}
+	if infodict != nil {
+		trailer["Info"] = infodict.ObjectNumber.Ref()
+	}
+	if sig := pw.signature; sig != nil {
+		if err = pw.writeSignatureAppearance(sig); err != nil {
+			return err
+		}
+		if err = pw.writeSignatureDict(sig); err != nil {
+			return err
+		}
+	}
+	if pw.security != nil {
+		encrypt, err := pw.writeEncryptDict()
+		if err != nil {
+			return err
+		}
+		trailer["Encrypt"] = encrypt.ObjectNumber.Ref()
+	}
+	if err = errors.Join(pw.conformanceErrors...); err != nil {
+		return err
+	}
+	if pw.fileID != nil {
+		id := hexString(pw.fileID)
+		trailer["ID"] = "[" + id + " " + id + "]"
+	}
+	if pw.ObjectStreams {
+		err = pw.writeXRefStream(trailer)
+	} else {
+		err = pw.writeXRefTable(trailer)
+	}
+	if err != nil {
+		return err
+	}
+	if pw.signature != nil {
+		if err = pw.finishSignature(); err != nil {
+			return err
+		}
+	}
+	if pw.signatureOut != nil {
+		// buffered for the signature, now copy to the real output
+		if _, err = pw.outfile.(*bytes.Buffer).WriteTo(pw.signatureOut); err != nil {
+			return err
+		}
+		pw.outfile = pw.signatureOut
+		pw.signatureOut = nil
+	}
+	pw.NoPages = len(pw.pages.Pages)
+	return nil
+}
+
+// writeXRefTable writes the classic xref section followed by the trailer
+// dictionary, which gets the /Size and /ID entries added.
+func (pw *PDF) writeXRefTable(trailer Dict) error {
 	type chunk struct {
 		positions []int64
 		startOnum Objectnumber
@@ -685,26 +952,18 @@ func (pw *PDF) Finish() error {
 	pw.Print(str.String())
 	sum := fmt.Sprintf("%X", md5.Sum([]byte(str.String())))
 
-	trailer := Dict{
-		"Size": strconv.Itoa(int(pw.nextobject)),
-		"Root": dc.Ref(),
-		"ID":   "[<" + sum + "> <" + sum + ">]",
-	}
-	if infodict != nil {
-		trailer["Info"] = infodict.ObjectNumber.Ref()
+	trailer["Size"] = strconv.Itoa(int(pw.nextobject))
+	if trailer["ID"] == nil {
+		trailer["ID"] = "[<" + sum + "> <" + sum + ">]"
 	}
 
-	if err = pw.Println("trailer"); err != nil {
+	if err := pw.Println("trailer"); err != nil {
 		return err
 	}
 
 	pw.outHash(trailer)
 
-	if err = pw.Printf("\nstartxref\n%d\n%%%%EOF\n", xrefpos); err != nil {
-		return err
-	}
-	pw.NoPages = len(pw.pages.Pages)
-	return nil
+	return pw.Printf("\nstartxref\n%d\n%%%%EOF\n", xrefpos)
 }
 
 // FinishAndClose writes the trailer and xref section and closes the file if it
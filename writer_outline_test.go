@@ -0,0 +1,98 @@
+package pdf
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// outlineCount returns the /Count of the outline entry with the title.
+func outlineCount(t *testing.T, out, title string) string {
+	t.Helper()
+	m := regexp.MustCompile(`(?s)/Count (-?\d+)[^>]*?/Title \(` + title + `\)`).FindStringSubmatch(out)
+	if m == nil {
+		m = regexp.MustCompile(`(?s)/Title \(` + title + `\)[^>]*?/Count (-?\d+)`).FindStringSubmatch(out)
+	}
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
+
+func TestOutlineCountClosedSubtrees(t *testing.T) {
+	pw, buf := newTestPDF()
+	page := pw.AddPage(pw.NewObject(), 0)
+	dest := &Destination{Page: page.Objnum, Fit: Fit}
+	leaf := func(title string) *Outline { return &Outline{Title: title, Destination: dest} }
+	pw.Outlines = []*Outline{
+		{Title: "A", Destination: dest, Open: true, Children: []*Outline{
+			{Title: "A1", Destination: dest, Children: []*Outline{leaf("A1a"), leaf("A1b"), leaf("A1c")}},
+			{Title: "A2", Destination: dest, Open: true, Children: []*Outline{leaf("A2a"), leaf("A2b")}},
+		}},
+		{Title: "B", Destination: dest, Children: []*Outline{
+			{Title: "B1", Destination: dest, Open: true, Children: []*Outline{leaf("B1a")}},
+		}},
+	}
+	if err := pw.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for title, want := range map[string]string{
+		"A":  "4",  // A1, A2, A2a, A2b
+		"A1": "-3", // closed
+		"A2": "2",
+		"B":  "-2", // B1 and B1a would be visible when B is opened
+		"B1": "1",
+	} {
+		if got := outlineCount(t, out, title); got != want {
+			t.Errorf("/Count of %s = %s, want %s", title, got, want)
+		}
+	}
+	// A, A1, A2, A2a, A2b, B
+	if !regexp.MustCompile(`/Type /Outlines\s+/Count 6\s`).MatchString(out) {
+		t.Error("wrong /Count in the outline root")
+	}
+}
+
+func TestOutlineAppearanceAndActions(t *testing.T) {
+	pw, buf := newTestPDF()
+	page := pw.AddPage(pw.NewObject(), 0)
+	st := pw.StructTree()
+	sect := st.Add("Sect")
+	pw.Outlines = []*Outline{
+		{Title: "Red bold", Dest: "(chapter1)", Color: []float64{1, 0, 0}, Style: OutlineBold},
+		{Title: "Both", Destination: &Destination{Page: page.Objnum}, Style: OutlineBold | OutlineItalic, StructElem: sect},
+		{Title: "Web", Action: &Action{Type: ActionURI, URI: "https://example.com"}},
+		{Title: "Print", Action: &Action{Type: ActionNamed, Name: "Print"}},
+	}
+	if err := pw.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"/C [1 0 0]",
+		"/F 2",
+		"/F 3",
+		fmt.Sprintf("/SE %s", sect.objnum.Ref()),
+		"/URI (https://example.com)",
+		"/N /Print",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q", want)
+		}
+	}
+
+	for _, o := range []*Outline{
+		{Title: "Both", Dest: "(x)", Action: &Action{Type: ActionNamed, Name: "Print"}},
+		{Title: "Gray", Dest: "(x)", Color: []float64{0.5}},
+		{Title: "Too bright", Dest: "(x)", Color: []float64{2, 0, 0}},
+	} {
+		pw, _ := newTestPDF()
+		pw.AddPage(pw.NewObject(), 0)
+		pw.Outlines = []*Outline{o}
+		if err := pw.Finish(); err == nil {
+			t.Errorf("expected an error for outline %q", o.Title)
+		}
+	}
+}
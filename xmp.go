@@ -0,0 +1,354 @@
+package pdf
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// pdfDocEncodingToUnicode is the reverse of unicodeToPDFDocEncoding.
+var pdfDocEncodingToUnicode = func() map[byte]rune {
+	m := make(map[byte]rune, len(unicodeToPDFDocEncoding))
+	for r, b := range unicodeToPDFDocEncoding {
+		m[b] = r
+	}
+	return m
+}()
+
+// textValue returns the text of a PDF string value as used in InfoDict. The
+// value can be a String, or a string that already holds the PDF
+// representation, such as the output of stringToPDF or pdfDate.
+func textValue(v any) string {
+	switch t := v.(type) {
+	case String:
+		return string(t)
+	case string:
+		t = strings.TrimSpace(t)
+		var b []byte
+		switch {
+		case strings.HasPrefix(t, "("):
+			b, _ = parseLiteralString([]byte(t))
+		case strings.HasPrefix(t, "<") && !strings.HasPrefix(t, "<<"):
+			b = decodeHexString([]byte(strings.Trim(t, "<>")))
+		default:
+			return t
+		}
+		if len(b) >= 2 && b[0] == 0xfe && b[1] == 0xff {
+			u := make([]uint16, 0, len(b)/2)
+			for i := 2; i+1 < len(b); i += 2 {
+				u = append(u, uint16(b[i])<<8|uint16(b[i+1]))
+			}
+			return string(utf16.Decode(u))
+		}
+		var sb strings.Builder
+		for _, c := range b {
+			if r, ok := pdfDocEncodingToUnicode[c]; ok {
+				sb.WriteRune(r)
+			} else {
+				sb.WriteRune(rune(c))
+			}
+		}
+		return sb.String()
+	}
+	return ""
+}
+
+// xmpDate converts a PDF date such as D:20251114123045+01'00' to the ISO 8601
+// form used in XMP (2025-11-14T12:30:45+01:00). Missing parts are omitted.
+func xmpDate(pdfdate string) string {
+	s := strings.TrimPrefix(pdfdate, "D:")
+	d := s[:len(s)-len(strings.TrimLeft(s, "0123456789"))]
+	tz := s[len(d):]
+	if len(d) < 4 {
+		return d
+	}
+	out := d[:4]
+	for i, sep := range []string{"-", "-", "T", ":", ":"} {
+		pos := 4 + 2*i
+		// XMP needs at least hours and minutes for a time
+		if len(d) < pos+2 || (sep == "T" && len(d) < 12) {
+			break
+		}
+		out += sep + d[pos:pos+2]
+	}
+	if len(d) < 12 {
+		return out
+	}
+	switch {
+	case len(tz) >= 3 && (tz[0] == '+' || tz[0] == '-'):
+		mm := strings.Trim(tz[3:], "'")
+		if len(mm) != 2 {
+			mm = "00"
+		}
+		out += tz[:3] + ":" + mm
+	default:
+		out += "Z"
+	}
+	return out
+}
+
+// xmlEscape returns s with the XML special characters escaped.
+func xmlEscape(s string) string {
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// Metadata describes the document. The writer serialises it as XMP in the
+// /Metadata stream of the catalog and, for PDF 1.7, mirrors it into the
+// document information dictionary. Entries set in InfoDict are used for the
+// fields that are empty here.
+type Metadata struct {
+	Title string
+	// Authors are joined with "; " for the /Author entry. PDF/A files get
+	// the joined string as their only dc:creator, as PDF/A requires.
+	Authors  []string
+	Subject  string
+	Keywords []string
+	// CreatorTool is the application that created the original document
+	// (/Creator in the information dictionary).
+	CreatorTool string
+	// Producer is the application that wrote the PDF.
+	Producer         string
+	CreationDate     time.Time
+	ModificationDate time.Time
+	// Language is the natural language of the document as a BCP 47 tag such
+	// as "en-US". It is also written to the /Lang entry of the catalog.
+	Language string
+	// Schemas holds additional XMP schemas, for example the Factur-X
+	// invoice description.
+	Schemas []XMPSchema
+}
+
+// XMPSchema is a custom XMP schema with simple text properties. For PDF/A
+// documents the writer adds the extension schema description that PDF/A
+// requires for all schemas not predefined by XMP.
+type XMPSchema struct {
+	Prefix       string
+	NamespaceURI string
+	// Description is the human-readable name of the schema, used in the
+	// PDF/A extension schema description.
+	Description string
+	Properties  []XMPProperty
+}
+
+// XMPProperty is a property of a custom XMP schema.
+type XMPProperty struct {
+	Name  string
+	Value string
+	// ValueType is the XMP value type of the property, defaults to "Text".
+	ValueType string
+	// Category is "internal" or "external" (the default).
+	Category    string
+	Description string
+}
+
+// syncMetadata copies the fields of pw.Metadata into InfoDict and fills in
+// the entries the writer always provides. This happens before the catalog
+// is written so that the XMP metadata sees the same values as the
+// information dictionary.
+func (pw *PDF) syncMetadata() {
+	if pw.InfoDict == nil {
+		pw.InfoDict = make(Dict)
+	}
+	if md := pw.Metadata; md != nil {
+		for _, e := range []struct {
+			key   Name
+			value string
+		}{
+			{"Title", md.Title},
+			{"Author", strings.Join(md.Authors, "; ")},
+			{"Subject", md.Subject},
+			{"Keywords", strings.Join(md.Keywords, ", ")},
+			{"Creator", md.CreatorTool},
+			{"Producer", md.Producer},
+		} {
+			if e.value != "" {
+				pw.InfoDict[e.key] = stringToPDF(e.value)
+			}
+		}
+		if !md.CreationDate.IsZero() {
+			pw.InfoDict["CreationDate"] = pdfDate(md.CreationDate)
+		}
+		if !md.ModificationDate.IsZero() {
+			pw.InfoDict["ModDate"] = pdfDate(md.ModificationDate)
+		}
+	}
+	if pw.InfoDict["Producer"] == nil {
+		pw.InfoDict["Producer"] = stringToPDF("baseline-pdf/boxes and glue")
+	}
+	if pw.InfoDict["Creator"] == nil {
+		pw.InfoDict["Creator"] = stringToPDF("baseline-pdf")
+	}
+	if pw.InfoDict["CreationDate"] == nil {
+		pw.InfoDict["CreationDate"] = pdfDate(time.Now())
+	}
+}
+
+// hasMetadataStream reports whether the catalog gets a /Metadata stream.
+// PDF 2.0 has no information dictionary, so XMP is the only place for the
+// document metadata.
+func (pw *PDF) hasMetadataStream() bool {
+	return pw.Metadata != nil || pw.PDFA != nil || !pw.version.hasInfoDict()
+}
+
+// xmpPacket returns the XMP metadata for the document, derived from the
+// entries of the document information dictionary so that both are in sync.
+func (pw *PDF) xmpPacket() []byte {
+	info := func(key Name) string {
+		if v, ok := pw.InfoDict[key]; ok {
+			return textValue(v)
+		}
+		return ""
+	}
+	md := pw.Metadata
+	if md == nil {
+		md = &Metadata{}
+	}
+	var b bytes.Buffer
+	b.WriteString("<?xpacket begin=\"\ufeff\" id=\"W5M0MpCehiHzreSzNTczkc9d\"?>\n")
+	b.WriteString(`<x:xmpmeta xmlns:x="adobe:ns:meta/">
+ <rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+  <rdf:Description rdf:about=""
+    xmlns:dc="http://purl.org/dc/elements/1.1/"
+    xmlns:xmp="http://ns.adobe.com/xap/1.0/"
+    xmlns:pdf="http://ns.adobe.com/pdf/1.3/"`)
+	if pw.PDFA != nil {
+		b.WriteString("\n    xmlns:pdfaid=\"http://www.aiim.org/pdfa/ns/id/\"")
+	}
+	b.WriteString(">\n")
+	if t := info("Title"); t != "" {
+		b.WriteString("   <dc:title><rdf:Alt><rdf:li xml:lang=\"x-default\">" + xmlEscape(t) + "</rdf:li></rdf:Alt></dc:title>\n")
+	}
+	authors := md.Authors
+	if len(authors) == 0 || pw.PDFA != nil {
+		// PDF/A requires dc:creator to be a single entry equal to the
+		// /Author of the information dictionary
+		authors = nil
+		if t := info("Author"); t != "" {
+			authors = []string{t}
+		}
+	}
+	if len(authors) > 0 {
+		b.WriteString("   <dc:creator><rdf:Seq>")
+		for _, a := range authors {
+			b.WriteString("<rdf:li>" + xmlEscape(a) + "</rdf:li>")
+		}
+		b.WriteString("</rdf:Seq></dc:creator>\n")
+	}
+	if t := info("Subject"); t != "" {
+		b.WriteString("   <dc:description><rdf:Alt><rdf:li xml:lang=\"x-default\">" + xmlEscape(t) + "</rdf:li></rdf:Alt></dc:description>\n")
+	}
+	if len(md.Keywords) > 0 {
+		b.WriteString("   <dc:subject><rdf:Bag>")
+		for _, k := range md.Keywords {
+			b.WriteString("<rdf:li>" + xmlEscape(k) + "</rdf:li>")
+		}
+		b.WriteString("</rdf:Bag></dc:subject>\n")
+	}
+	if md.Language != "" {
+		b.WriteString("   <dc:language><rdf:Bag><rdf:li>" + xmlEscape(md.Language) + "</rdf:li></rdf:Bag></dc:language>\n")
+	}
+	for _, p := range []struct {
+		key  Name
+		prop string
+	}{
+		{"Keywords", "pdf:Keywords"},
+		{"Producer", "pdf:Producer"},
+		{"Creator", "xmp:CreatorTool"},
+		{"CreationDate", "xmp:CreateDate"},
+		{"ModDate", "xmp:ModifyDate"},
+	} {
+		t := info(p.key)
+		if t == "" {
+			continue
+		}
+		if strings.HasSuffix(string(p.key), "Date") {
+			t = xmpDate(t)
+		}
+		b.WriteString("   <" + p.prop + ">" + xmlEscape(t) + "</" + p.prop + ">\n")
+		if p.key == "ModDate" {
+			b.WriteString("   <xmp:MetadataDate>" + xmlEscape(t) + "</xmp:MetadataDate>\n")
+		}
+	}
+	if t, ok := pw.InfoDict["Trapped"]; ok {
+		b.WriteString("   <pdf:Trapped>" + strings.TrimPrefix(fmt.Sprint(t), "/") + "</pdf:Trapped>\n")
+	}
+	if pw.PDFA != nil {
+		b.WriteString("   <pdfaid:part>" + pw.PDFA.Level.part() + "</pdfaid:part>\n")
+		b.WriteString("   <pdfaid:conformance>B</pdfaid:conformance>\n")
+	}
+	b.WriteString("  </rdf:Description>\n")
+	for _, s := range md.Schemas {
+		b.WriteString("  <rdf:Description rdf:about=\"\" xmlns:" + s.Prefix + "=\"" + xmlEscape(s.NamespaceURI) + "\">\n")
+		for _, p := range s.Properties {
+			b.WriteString("   <" + s.Prefix + ":" + p.Name + ">" + xmlEscape(p.Value) + "</" + s.Prefix + ":" + p.Name + ">\n")
+		}
+		b.WriteString("  </rdf:Description>\n")
+	}
+	if pw.PDFA != nil && len(md.Schemas) > 0 {
+		writeXMPExtensionSchemas(&b, md.Schemas)
+	}
+	b.WriteString(" </rdf:RDF>\n</x:xmpmeta>\n")
+	// padding allows in-place updates of the packet
+	for range 20 {
+		b.WriteString(strings.Repeat(" ", 99) + "\n")
+	}
+	b.WriteString(`<?xpacket end="w"?>`)
+	return b.Bytes()
+}
+
+// writeXMPExtensionSchemas writes the PDF/A extension schema description
+// for the custom schemas.
+func writeXMPExtensionSchemas(b *bytes.Buffer, schemas []XMPSchema) {
+	b.WriteString(`  <rdf:Description rdf:about=""
+    xmlns:pdfaExtension="http://www.aiim.org/pdfa/ns/extension/"
+    xmlns:pdfaSchema="http://www.aiim.org/pdfa/ns/schema#"
+    xmlns:pdfaProperty="http://www.aiim.org/pdfa/ns/property#">
+   <pdfaExtension:schemas>
+    <rdf:Bag>
+`)
+	for _, s := range schemas {
+		b.WriteString("     <rdf:li rdf:parseType=\"Resource\">\n")
+		b.WriteString("      <pdfaSchema:schema>" + xmlEscape(s.Description) + "</pdfaSchema:schema>\n")
+		b.WriteString("      <pdfaSchema:namespaceURI>" + xmlEscape(s.NamespaceURI) + "</pdfaSchema:namespaceURI>\n")
+		b.WriteString("      <pdfaSchema:prefix>" + s.Prefix + "</pdfaSchema:prefix>\n")
+		b.WriteString("      <pdfaSchema:property>\n       <rdf:Seq>\n")
+		for _, p := range s.Properties {
+			valueType, category := p.ValueType, p.Category
+			if valueType == "" {
+				valueType = "Text"
+			}
+			if category == "" {
+				category = "external"
+			}
+			b.WriteString("        <rdf:li rdf:parseType=\"Resource\">\n")
+			b.WriteString("         <pdfaProperty:name>" + p.Name + "</pdfaProperty:name>\n")
+			b.WriteString("         <pdfaProperty:valueType>" + valueType + "</pdfaProperty:valueType>\n")
+			b.WriteString("         <pdfaProperty:category>" + category + "</pdfaProperty:category>\n")
+			b.WriteString("         <pdfaProperty:description>" + xmlEscape(p.Description) + "</pdfaProperty:description>\n")
+			b.WriteString("        </rdf:li>\n")
+		}
+		b.WriteString("       </rdf:Seq>\n      </pdfaSchema:property>\n     </rdf:li>\n")
+	}
+	b.WriteString("    </rdf:Bag>\n   </pdfaExtension:schemas>\n  </rdf:Description>\n")
+}
+
+// writeMetadata writes the XMP packet as the document's metadata stream. The
+// stream is not compressed so that the metadata can be found by tools that do
+// not understand PDF.
+func (pw *PDF) writeMetadata() (*Object, error) {
+	md := pw.NewObject()
+	md.Dictionary = Dict{
+		"Type":    "/Metadata",
+		"Subtype": "/XML",
+	}
+	md.Data.Write(pw.xmpPacket())
+	if err := md.Save(); err != nil {
+		return nil, err
+	}
+	return md, nil
+}
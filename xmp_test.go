@@ -0,0 +1,156 @@
+package pdf
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetadataVersion20(t *testing.T) {
+	pw, buf := newTestPDF()
+	pw.SetVersion(Version20)
+	pw.Metadata = &Metadata{
+		Title:    "Annual report",
+		Authors:  []string{"Jane Doe", "John Roe"},
+		Keywords: []string{"report", "2025"},
+		Language: "en-US",
+	}
+	pw.AddPage(pw.NewObject(), 0)
+	if err := pw.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if strings.Contains(out, "/Info ") {
+		t.Error("PDF 2.0 must not have an information dictionary")
+	}
+	for _, want := range []string{
+		"/Metadata ",
+		"/Lang (en-US)",
+		"<rdf:Seq><rdf:li>Jane Doe</rdf:li><rdf:li>John Roe</rdf:li></rdf:Seq>",
+		"<dc:subject><rdf:Bag><rdf:li>report</rdf:li><rdf:li>2025</rdf:li></rdf:Bag></dc:subject>",
+		"<dc:language><rdf:Bag><rdf:li>en-US</rdf:li></rdf:Bag></dc:language>",
+		"<pdf:Keywords>report, 2025</pdf:Keywords>",
+		"<pdf:Producer>baseline-pdf/boxes and glue</pdf:Producer>",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q", want)
+		}
+	}
+}
+
+func TestMetadataMirroredToInfo(t *testing.T) {
+	pw, buf := newTestPDF()
+	created := time.Date(2025, 11, 14, 12, 30, 45, 0, time.UTC)
+	pw.InfoDict["Subject"] = stringToPDF("from info")
+	pw.Metadata = &Metadata{
+		Title:        "Report",
+		Authors:      []string{"Jane Doe", "John Roe"},
+		CreatorTool:  "Writer 1.0",
+		Producer:     "Producer 2.0",
+		CreationDate: created,
+	}
+	pw.AddPage(pw.NewObject(), 0)
+	if err := pw.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"/Title (Report)",
+		"/Author (Jane Doe; John Roe)",
+		"/Creator (Writer 1.0)",
+		"/Producer (Producer 2.0)",
+		"/CreationDate (D:20251114123045+00'00')",
+		"<xmp:CreatorTool>Writer 1.0</xmp:CreatorTool>",
+		"<xmp:CreateDate>2025-11-14T12:30:45+00:00</xmp:CreateDate>",
+		`<dc:description><rdf:Alt><rdf:li xml:lang="x-default">from info</rdf:li></rdf:Alt></dc:description>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q", want)
+		}
+	}
+}
+
+func TestMetadataPDFAAuthors(t *testing.T) {
+	pw, buf := newTestPDF()
+	pw.PDFA = &PDFA{Level: PDFA2b, ICCProfile: testICCProfile("RGB ")}
+	pw.Metadata = &Metadata{Authors: []string{"Jane Doe", "John Roe"}}
+	pw.AddPage(pw.NewObject(), 0)
+	if err := pw.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"/Author (Jane Doe; John Roe)",
+		"<dc:creator><rdf:Seq><rdf:li>Jane Doe; John Roe</rdf:li></rdf:Seq></dc:creator>",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q", want)
+		}
+	}
+}
+
+func TestMetadataCustomSchema(t *testing.T) {
+	pw, buf := newTestPDF()
+	pw.PDFA = &PDFA{Level: PDFA3b, ICCProfile: testICCProfile("RGB ")}
+	pw.Metadata = &Metadata{
+		Schemas: []XMPSchema{{
+			Prefix:       "fx",
+			NamespaceURI: "urn:factur-x:pdfa:CrossIndustryDocument:invoice:1p0#",
+			Description:  "Factur-X PDFA Extension Schema",
+			Properties: []XMPProperty{
+				{Name: "DocumentType", Value: "INVOICE", Description: "INVOICE"},
+				{Name: "DocumentFileName", Value: "factur-x.xml", Description: "Name of the embedded XML invoice file"},
+			},
+		}},
+	}
+	pw.AddPage(pw.NewObject(), 0)
+	if err := pw.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		`xmlns:fx="urn:factur-x:pdfa:CrossIndustryDocument:invoice:1p0#"`,
+		"<fx:DocumentType>INVOICE</fx:DocumentType>",
+		"<fx:DocumentFileName>factur-x.xml</fx:DocumentFileName>",
+		"<pdfaSchema:prefix>fx</pdfaSchema:prefix>",
+		"<pdfaProperty:name>DocumentFileName</pdfaProperty:name>",
+		"<pdfaProperty:valueType>Text</pdfaProperty:valueType>",
+		"<pdfaProperty:category>external</pdfaProperty:category>",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q", want)
+		}
+	}
+}
+
+func TestXMPDate(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"D:20251114123045+01'00'", "2025-11-14T12:30:45+01:00"},
+		{"(D:20251114123045-05'30')", "2025-11-14T12:30:45-05:30"},
+		{"D:20251114123045Z", "2025-11-14T12:30:45Z"},
+		{"D:2025", "2025"},
+		{"D:20251114", "2025-11-14"},
+	}
+	for _, tt := range tests {
+		if got := xmpDate(textValue(tt.in)); got != tt.want {
+			t.Errorf("xmpDate(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestTextValue(t *testing.T) {
+	tests := []struct {
+		in   any
+		want string
+	}{
+		{String("plain"), "plain"},
+		{stringToPDF("a (b) c"), "a (b) c"},
+		{stringToPDF("日本"), "日本"},
+		{stringToPDF("Grüße"), "Grüße"},
+	}
+	for _, tt := range tests {
+		if got := textValue(tt.in); got != tt.want {
+			t.Errorf("textValue(%v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}